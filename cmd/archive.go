@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive --bagit (<rev> <dir> | --validate <dir> [<rev>])",
+	Short: "package a version for a preservation repository, or validate such a package",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		bagit, err := cmd.Flags().GetBool("bagit")
+		if err != nil {
+			return err
+		}
+		validate, err := cmd.Flags().GetBool("validate")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if !bagit {
+			return fmt.Errorf("no archive format; see --bagit")
+		} else if !validate && len(args) != 2 {
+			return fmt.Errorf("expected a revision and a directory")
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		if !validate {
+			version, err := dorothy.WriteBag(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("wrote version %s to bag %q\n", version.Hash, args[1])
+			return nil
+		}
+
+		var rev string
+		if len(args) == 2 {
+			rev = args[1]
+		}
+		version, problems, err := dorothy.ValidateBagVersion(args[0], rev)
+		if err != nil {
+			return err
+		}
+
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) != 0 {
+			return fmt.Errorf("found %d problem(s) in bag %q", len(problems), args[0])
+		}
+
+		fmt.Printf("bag %q is valid and holds version %s\n", args[0], version.Hash)
+		return nil
+	}),
+}
+
+func init() {
+	archiveCmd.Flags().Bool("bagit", false, "write or validate a BagIt bag")
+	archiveCmd.Flags().Bool("validate", false, "check a bag against a version instead of writing one")
+	rootCmd.AddCommand(archiveCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "interactively browse the version history",
+	Args:  cobra.ExactArgs(0),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		return dorothy.Browse()
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+}
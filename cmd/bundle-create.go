@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create [<rev> | <rev>..<rev>]...",
+	Short: "write the manifest and the content of versions to a bundle",
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if output == "" {
+			return fmt.Errorf("no output file; see --output")
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "-" {
+			handle, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer handle.Close()
+			w = handle
+		}
+
+		bundle, err := dorothy.CreateBundle(w, args...)
+		if err != nil {
+			if output != "-" {
+				os.Remove(output)
+			}
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "bundled manifest %s with %d of %d version(s)\n", bundle.Manifest, len(bundle.Versions), len(dorothy.Manifest.Versions))
+		return nil
+	}),
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringP("output", "o", "", "file to write the bundle to, or - for standard output")
+	bundleCmd.AddCommand(bundleCreateCmd)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var bundleFetchCmd = &cobra.Command{
+	Use:   "fetch file",
+	Short: "merge the manifest and content of a bundle into the repository",
+	Args:  cobra.ExactArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		handle, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer handle.Close()
+
+		fetched, conflicts, err := dorothy.FetchBundle(handle, args[0])
+		if len(conflicts) != 0 {
+			fmt.Fprintf(os.Stderr, "conflicts:\n")
+			for _, conflict := range conflicts {
+				fmt.Fprintf(os.Stderr, "  %s", conflict)
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("fetch failed - %v\n", err)
+		} else if len(conflicts) != 0 {
+			return fmt.Errorf("fetch failed\n")
+		}
+
+		fmt.Printf("imported %d block(s)\n", fetched.Blocks)
+		for _, version := range fetched.Versions {
+			if version.IsComplete() {
+				fmt.Printf("complete %s\n", version.Hash)
+			} else {
+				fmt.Printf("incomplete %s: %d block(s) missing\n", version.Hash, len(version.Missing))
+			}
+		}
+		return nil
+	}),
+}
+
+func init() {
+	bundleCmd.AddCommand(bundleFetchCmd)
+}
@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "move datasets between repositories as files",
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var catCmd = &cobra.Command{
+	Use:   "cat rev[:path]",
+	Short: "stream a file from a version to stdout",
+	Args:  cobra.ExactArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		offset, err := cmd.Flags().GetInt64("offset")
+		if err != nil {
+			return err
+		}
+		length, err := cmd.Flags().GetInt64("length")
+		if err != nil {
+			return err
+		}
+
+		if offset < 0 {
+			return fmt.Errorf("offset must be non-negative")
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		file, err := dorothy.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if offset != 0 {
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+
+		var r io.Reader = file
+		if length >= 0 {
+			r = io.LimitReader(file, length)
+		}
+
+		_, err = io.Copy(os.Stdout, r)
+		return err
+	}),
+}
+
+func init() {
+	catCmd.Flags().Int64P("offset", "o", 0, "byte offset at which to start reading")
+	catCmd.Flags().Int64P("length", "l", -1, "maximum number of bytes to read (negative reads to the end)")
+	rootCmd.AddCommand(catCmd)
+}
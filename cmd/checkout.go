@@ -1,12 +1,67 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
 	"github.com/39alpha/dorothy/core"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+type checkoutProgressBar struct {
+	sync.Mutex
+	bar     progress.Model
+	updated time.Time
+}
+
+func newCheckoutProgressBar() *checkoutProgressBar {
+	return &checkoutProgressBar{
+		bar: progress.New(progress.WithDefaultGradient(), progress.WithWidth(30)),
+	}
+}
+
+func (p *checkoutProgressBar) Update(status core.CheckoutProgress) {
+	p.Lock()
+	defer p.Unlock()
+
+	done := status.Files == status.TotalFiles
+	if !done && time.Since(p.updated) < 100*time.Millisecond {
+		return
+	}
+	p.updated = time.Now()
+
+	percent := 1.0
+	if status.TotalBytes != 0 {
+		percent = float64(status.Bytes) / float64(status.TotalBytes)
+	}
+	fmt.Fprintf(
+		os.Stderr,
+		"\r%s %s / %s, %d / %d files\033[K",
+		p.bar.ViewAs(percent),
+		core.FormatBytes(status.Bytes),
+		core.FormatBytes(status.TotalBytes),
+		status.Files,
+		status.TotalFiles,
+	)
+}
+
+func (p *checkoutProgressBar) Finish() {
+	p.Lock()
+	defer p.Unlock()
+	if !p.updated.IsZero() {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
 var checkoutCmd = &cobra.Command{
-	Use:   "checkout hash dest",
+	Use:   "checkout rev[:path] dest",
 	Short: "checkout a version to a specific destination",
 	Args:  cobra.ExactArgs(2),
 	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
@@ -18,12 +73,40 @@ var checkoutCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		include, err := cmd.Flags().GetStringSlice("include")
+		if err != nil {
+			return err
+		}
+		exclude, err := cmd.Flags().GetStringSlice("exclude")
+		if err != nil {
+			return err
+		}
+		jobs, err := cmd.Flags().GetInt("jobs")
+		if err != nil {
+			return err
+		}
+		quiet, err := cmd.Flags().GetBool("quiet")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		track, err := cmd.Flags().GetBool("track")
+		if err != nil {
+			return err
+		}
 
 		dorothy, err := core.NewDorothy()
 		if err != nil {
 			return err
 		}
 
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		dorothy.Context = ctx
+
 		if noinherit {
 			if err := dorothy.ResetConfig(); err != nil {
 				return err
@@ -40,10 +123,38 @@ var checkoutCmd = &cobra.Command{
 			return err
 		}
 
-		return dorothy.Checkout(args[0], args[1])
+		options := []core.CheckoutOption{
+			core.CheckoutInclude(include...),
+			core.CheckoutExclude(exclude...),
+			core.CheckoutWorkers(jobs),
+			core.CheckoutForce(force),
+			core.CheckoutWithUntracked(func(path string) {
+				fmt.Fprintf(os.Stderr, "leaving untracked file %s\n", path)
+			}),
+		}
+		if track {
+			options = append(options, core.CheckoutTrack(true))
+		}
+		if !quiet && term.IsTerminal(int(os.Stderr.Fd())) {
+			bar := newCheckoutProgressBar()
+			defer bar.Finish()
+			options = append(options, core.CheckoutWithProgress(bar.Update))
+		}
+
+		err = dorothy.Checkout(args[0], args[1], options...)
+		if errors.Is(err, core.ErrLocalChanges) {
+			return fmt.Errorf("%v\nuse --force to discard them", err)
+		}
+		return err
 	}),
 }
 
 func init() {
+	checkoutCmd.Flags().StringSliceP("include", "i", nil, "only checkout paths matching these glob patterns")
+	checkoutCmd.Flags().StringSliceP("exclude", "e", nil, "do not checkout paths matching these glob patterns")
+	checkoutCmd.Flags().IntP("jobs", "j", 0, "number of files to fetch concurrently (default from config, or 4)")
+	checkoutCmd.Flags().BoolP("quiet", "q", false, "do not show a progress bar")
+	checkoutCmd.Flags().BoolP("force", "f", false, "overwrite or remove files with uncommitted changes")
+	checkoutCmd.Flags().BoolP("track", "t", false, "track the destination as a working tree, removing files deleted since its last checkout")
 	rootCmd.AddCommand(checkoutCmd)
 }
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var checksumCmd = &cobra.Command{
+	Use:   "checksum [<rev>]",
+	Short: "print the checksums of the files of a version in sha256sum format",
+	Args:  cobra.MaximumNArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		withMd5, err := cmd.Flags().GetBool("md5")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		rev := ""
+		if len(args) == 1 {
+			rev = args[0]
+		} else if leaves := dorothy.Manifest.LeafVersions(); len(leaves) == 1 {
+			rev = leaves[0].Hash
+		} else {
+			return fmt.Errorf("manifest has %d latest versions; choose one", len(leaves))
+		}
+
+		checksums, _, err := dorothy.Checksums(rev, withMd5)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range checksums.Files {
+			if withMd5 {
+				fmt.Printf("%s  %s\n", file.Md5, file.Path)
+			} else {
+				fmt.Printf("%s  %s\n", file.Sha256, file.Path)
+			}
+		}
+		return nil
+	}),
+}
+
+func init() {
+	checksumCmd.Flags().Bool("md5", false, "print md5 checksums in md5sum format instead")
+	rootCmd.AddCommand(checksumCmd)
+}
@@ -6,14 +6,18 @@ import (
 )
 
 var cloneCmd = &cobra.Command{
-	Use:   "clone remote",
-	Short: "clone a remote dataset",
+	Use:   "clone remote|bundle",
+	Short: "clone a remote dataset or create one from a bundle",
 	Args:  cobra.RangeArgs(1, 2),
 	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
 		global, err := cmd.Flags().GetBool("global")
 		if err != nil {
 			return err
 		}
+		shared, err := cmd.Flags().GetBool("shared")
+		if err != nil {
+			return err
+		}
 
 		var dest string
 		if len(args) == 2 {
@@ -22,7 +26,7 @@ var cloneCmd = &cobra.Command{
 			dest = ""
 		}
 
-		if _, err := core.Clone(args[0], dest, global); err != nil {
+		if _, err := core.Clone(args[0], dest, global, shared); err != nil {
 			return err
 		}
 
@@ -32,5 +36,6 @@ var cloneCmd = &cobra.Command{
 
 func init() {
 	cloneCmd.Flags().BoolP("global", "g", false, "initialize the repository to use a global IPFS instance")
+	cloneCmd.Flags().BoolP("shared", "s", false, "initialize the repository to use the per-user shared IPFS repo")
 	rootCmd.AddCommand(cloneCmd)
 }
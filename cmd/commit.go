@@ -81,6 +81,10 @@ var commitCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		nocopy, err := cmd.Flags().GetBool("nocopy")
+		if err != nil {
+			return err
+		}
 		parents, err := cmd.Flags().GetStringSlice("parents")
 		if err != nil {
 			return err
@@ -110,6 +114,12 @@ var commitCmd = &cobra.Command{
 			return err
 		}
 
+		if len(parents) == 0 && !pick && len(args) == 1 {
+			if base := dorothy.BaseVersion(args[0]); base != "" {
+				parents = []string{base}
+			}
+		}
+
 		parents, ok, err := checkParentage(dorothy, parents, pick)
 		if err != nil {
 			return fmt.Errorf("%v; aborting commit\n", err)
@@ -124,7 +134,7 @@ var commitCmd = &cobra.Command{
 			}
 		}
 
-		conflicts, err := dorothy.Commit(args, message, nopin, parents)
+		conflicts, err := dorothy.Commit(args, message, nopin, nocopy, parents)
 		if len(conflicts) != 0 {
 			fmt.Fprintf(os.Stderr, "conflicts:\n")
 			for _, conflict := range conflicts {
@@ -139,6 +149,7 @@ func init() {
 	rootCmd.AddCommand(commitCmd)
 	commitCmd.Flags().StringP("message", "m", "", "commit message")
 	commitCmd.Flags().BoolP("no-pin", "N", false, "do not pin the data to your local node")
+	commitCmd.Flags().Bool("nocopy", false, "reference files in place rather than copying them into the block store")
 	commitCmd.Flags().StringSliceP("parents", "p", nil, "parents of this commit")
-	commitCmd.Flags().BoolP("pick", "P", false, "interactively choose parents (implied by empty --partents)")
+	commitCmd.Flags().BoolP("pick", "P", false, "interactively choose parents (implied by empty --parents unless the path was checked out)")
 }
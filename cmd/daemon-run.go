@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var daemonRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "run the daemon in the foreground",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		offline, err := cmd.Flags().GetBool("offline")
+		if err != nil {
+			return err
+		}
+		idle, err := cmd.Flags().GetDuration("idle-timeout")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		dorothy.Context = ctx
+
+		if offline {
+			return dorothy.RunDaemon(idle, core.IpfsOffline)
+		}
+		return dorothy.RunDaemon(idle, core.IpfsOnline)
+	}),
+}
+
+func init() {
+	daemonRunCmd.Flags().Bool("offline", false, "do not connect to the IPFS network")
+	daemonRunCmd.Flags().Duration("idle-timeout", 0, "stop after no command has used the daemon for this long (default never)")
+	daemonCmd.AddCommand(daemonRunCmd)
+}
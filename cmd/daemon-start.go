@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+// autoDaemonIdleTimeout is how long a daemon started automatically for a
+// shared IPFS repository keeps running after its last request.
+const autoDaemonIdleTimeout = time.Minute
+
+// startDaemon runs `dorothy daemon run` in the background for the dataset and
+// waits for it to accept connections, returning its process id.
+func startDaemon(dorothy *core.Dorothy, configpath string, noinherit, offline bool, idle time.Duration) (int, error) {
+	status := dorothy.DaemonStatus()
+	if status.Running {
+		return 0, fmt.Errorf("daemon already running (pid %d)", status.Pid)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	runArgs := []string{"daemon", "run"}
+	if configpath != "" {
+		runArgs = append(runArgs, "--config", configpath)
+	}
+	if noinherit {
+		runArgs = append(runArgs, "--noinherit")
+	}
+	if offline {
+		runArgs = append(runArgs, "--offline")
+	}
+	if idle > 0 {
+		runArgs = append(runArgs, "--idle-timeout", idle.String())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(status.Socket), 0700); err != nil {
+		return 0, err
+	}
+	logfile, err := os.OpenFile(core.DaemonLogPath(status.Socket), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer logfile.Close()
+
+	// Descriptors inherited from whatever started dorothy are not
+	// close-on-exec, and the daemon would otherwise hold them open long after
+	// this command has exited.
+	if entries, err := os.ReadDir("/dev/fd"); err == nil {
+		for _, entry := range entries {
+			if fd, err := strconv.Atoi(entry.Name()); err == nil && fd > 2 {
+				syscall.CloseOnExec(fd)
+			}
+		}
+	}
+
+	daemon := exec.Command(executable, runArgs...)
+	daemon.Stdout = logfile
+	daemon.Stderr = logfile
+	daemon.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := daemon.Start(); err != nil {
+		return 0, err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- daemon.Wait()
+	}()
+
+	started := make(chan error, 1)
+	go func() {
+		started <- dorothy.WaitForDaemon(time.Minute)
+	}()
+
+	select {
+	case err := <-started:
+		if err != nil {
+			return 0, err
+		}
+	case <-exited:
+		return 0, fmt.Errorf("daemon exited during startup; see %s", core.DaemonLogPath(status.Socket))
+	}
+
+	return daemon.Process.Pid, nil
+}
+
+var daemonStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "start the daemon in the background",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		offline, err := cmd.Flags().GetBool("offline")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if !dorothy.IsInitialized() {
+			return fmt.Errorf("not a dorothy repository")
+		}
+
+		pid, err := startDaemon(dorothy, configpath, noinherit, offline, 0)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("daemon started (pid %d)\n", pid)
+		return nil
+	}),
+}
+
+func init() {
+	core.DaemonLauncher = func(dorothy *core.Dorothy) error {
+		configpath, err := rootCmd.PersistentFlags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := rootCmd.PersistentFlags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		_, err = startDaemon(dorothy, configpath, noinherit, false, autoDaemonIdleTimeout)
+		return err
+	}
+
+	daemonStartCmd.Flags().Bool("offline", false, "do not connect to the IPFS network")
+	daemonCmd.AddCommand(daemonStartCmd)
+}
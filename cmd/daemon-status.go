@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show whether the daemon is running",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		status := dorothy.DaemonStatus()
+		if !status.Running {
+			fmt.Println("daemon is not running")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Status:\trunning\n")
+		fmt.Fprintf(w, "Pid:\t%d\n", status.Pid)
+		fmt.Fprintf(w, "Socket:\t%s\n", status.Socket)
+		fmt.Fprintf(w, "Peer ID:\t%s\n", status.Identity)
+		fmt.Fprintf(w, "Log:\t%s\n", core.DaemonLogPath(status.Socket))
+		return w.Flush()
+	}),
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonStatusCmd)
+}
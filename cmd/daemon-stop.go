@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "stop the daemon",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.StopDaemon(); err != nil {
+			return err
+		}
+
+		fmt.Println("daemon stopped")
+		return nil
+	}),
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonStopCmd)
+}
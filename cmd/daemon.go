@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "keep an IPFS node running for faster commands",
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe [<rev>]",
+	Short: "write an RO-Crate or Data Package description of a version",
+	Args:  cobra.MaximumNArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		describeFormat := core.DescribeFormat(format)
+		if !describeFormat.IsValid() {
+			return fmt.Errorf("unsupported description format %q", format)
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		rev := ""
+		if len(args) == 1 {
+			rev = args[0]
+		} else if leaves := dorothy.Manifest.LeafVersions(); len(leaves) == 1 {
+			rev = leaves[0].Hash
+		} else {
+			return fmt.Errorf("manifest has %d latest versions; choose one", len(leaves))
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" && output != "-" {
+			handle, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer handle.Close()
+			w = handle
+		}
+
+		if err := dorothy.Describe(rev, describeFormat, w); err != nil {
+			if output != "" && output != "-" {
+				os.Remove(output)
+			}
+			return err
+		}
+		return nil
+	}),
+}
+
+func init() {
+	describeCmd.Flags().StringP("format", "f", core.DescribeFormatRoCrate.String(), "description format (ro-crate or datapackage)")
+	describeCmd.Flags().StringP("output", "o", "", "file to write the description to, or - for standard output")
+	rootCmd.AddCommand(describeCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var duCmd = &cobra.Command{
+	Use:   "du [rev...]",
+	Short: "report the storage used by versions and the whole history",
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		usage, err := dorothy.DiskUsage(args...)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", "VERSION", "LOGICAL", "STORED", "UNIQUE", "BLOCKS")
+		for _, version := range usage.Versions {
+			logical := core.FormatBytes(version.Logical)
+			if version.Incomplete {
+				logical = "-"
+			}
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%s\t%d\t\n",
+				version.Hash,
+				logical,
+				core.FormatBytes(version.Stored),
+				core.FormatBytes(version.Unique),
+				version.Blocks,
+			)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf(
+			"history: %d version(s), %s logical, %s stored in %d block(s), deduplication %.2fx\n",
+			len(dorothy.Manifest.Versions),
+			core.FormatBytes(usage.Logical),
+			core.FormatBytes(usage.Stored),
+			usage.Blocks,
+			usage.DedupRatio(),
+		)
+		if usage.Incomplete != 0 {
+			fmt.Printf("%d version(s) are not fully available locally and count only their local blocks\n", usage.Incomplete)
+		}
+		return nil
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(duCmd)
+}
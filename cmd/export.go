@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <rev>[:<path>]",
+	Short: "write a version to a CAR, tar, zip or tar.zst archive",
+	Args:  cobra.ExactArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		archiveFormat := core.ArchiveFormat(format)
+		if format == "" {
+			var ok bool
+			if archiveFormat, ok = core.ArchiveFormatOf(output); !ok {
+				archiveFormat = core.ArchiveFormatTar
+			}
+		}
+		if !archiveFormat.IsValid() {
+			return fmt.Errorf("unsupported archive format %q", format)
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" && output != "-" {
+			handle, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer handle.Close()
+			w = handle
+		}
+
+		if err := dorothy.Export(args[0], archiveFormat, w); err != nil {
+			if output != "" && output != "-" {
+				os.Remove(output)
+			}
+			return err
+		}
+		return nil
+	}),
+}
+
+func init() {
+	exportCmd.Flags().StringP("format", "f", "", "archive format (car, tar, zip or tar.zst); guessed from --output by default")
+	exportCmd.Flags().StringP("output", "o", "", "file to write the archive to, or - for standard output")
+	rootCmd.AddCommand(exportCmd)
+}
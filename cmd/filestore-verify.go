@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var filestoreVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "check that files referenced by --nocopy commits have not changed",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		entries, err := dorothy.VerifyFilestore()
+		if err != nil {
+			return err
+		}
+
+		var failed int
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, entry := range entries {
+			if !entry.IsOk() {
+				failed++
+			} else if !all {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", entry.Status, entry.Path, entry.Offset, entry.Cid)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if failed != 0 {
+			return fmt.Errorf("%d of %d blocks no longer match the files they reference", failed, len(entries))
+		}
+		return nil
+	}),
+}
+
+func init() {
+	filestoreVerifyCmd.Flags().BoolP("all", "a", false, "list every referenced block, not just those that fail")
+	filestoreCmd.AddCommand(filestoreVerifyCmd)
+}
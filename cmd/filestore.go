@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var filestoreCmd = &cobra.Command{
+	Use:   "filestore",
+	Short: "inspect data committed with --nocopy",
+}
+
+func init() {
+	rootCmd.AddCommand(filestoreCmd)
+}
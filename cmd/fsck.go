@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "check the integrity of the repository",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		repair, err := cmd.Flags().GetBool("repair")
+		if err != nil {
+			return err
+		}
+		verbose, err := cmd.Flags().GetBool("verbose")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		dorothy.Context = ctx
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if !dorothy.IsInitialized() {
+			return fmt.Errorf("not a dorothy repository")
+		}
+
+		// The manifest is not loaded up front since it may be what is broken.
+		mode := core.IpfsOffline
+		if repair {
+			mode = core.IpfsOnline
+		}
+		if err := dorothy.ConnectIpfs(mode); err != nil {
+			return err
+		}
+
+		report, err := dorothy.Fsck(repair)
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range report.Repaired {
+			fmt.Printf("repaired %s\n", hash)
+		}
+		for _, problem := range report.Problems {
+			fmt.Println(problem)
+			if verbose {
+				for _, block := range problem.Missing {
+					fmt.Printf("    %s\n", block)
+				}
+			}
+		}
+
+		if len(report.Problems) != 0 {
+			return fmt.Errorf("found %d problem(s) in manifest %s", len(report.Problems), report.Manifest)
+		}
+
+		fmt.Printf("checked %d version(s) and %d block(s) in manifest %s\n", report.Versions, report.Blocks, report.Manifest)
+		return nil
+	}),
+}
+
+func init() {
+	fsckCmd.Flags().Bool("repair", false, "fetch and pin missing blocks from peers")
+	fsckCmd.Flags().BoolP("verbose", "v", false, "list the missing blocks of each version")
+	rootCmd.AddCommand(fsckCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "apply the retention policy and remove unpinned content",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+
+		result, err := dorothy.GC(dryRun, force)
+		if err != nil {
+			return err
+		}
+
+		verb := "unpinned"
+		if dryRun {
+			verb = "would unpin"
+		}
+		for _, version := range result.Unpinned {
+			fmt.Printf("%s %s\n", verb, version.Hash)
+		}
+		for _, version := range result.Shared {
+			fmt.Printf("kept %s, which other datasets use\n", version.Hash)
+		}
+
+		if !dryRun {
+			fmt.Printf("removed %d block(s)\n", result.Removed)
+		}
+		return nil
+	}),
+}
+
+func init() {
+	gcCmd.Flags().Bool("dry-run", false, "only list the versions which would be unpinned")
+	gcCmd.Flags().Bool("force", false, "collect garbage even in an IPFS store shared with other datasets")
+	rootCmd.AddCommand(gcCmd)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "export the version graph",
+	Args:  cobra.ExactArgs(0),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+
+		graphFormat := core.GraphFormat(format)
+		if !graphFormat.IsValid() {
+			return fmt.Errorf("unsupported format %q; expected one of %v", format, core.AllGraphFormat)
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		tags, err := dorothy.LoadTags()
+		if err != nil {
+			return err
+		}
+
+		return dorothy.Manifest.WriteGraph(os.Stdout, graphFormat, tags)
+	}),
+}
+
+func init() {
+	graphCmd.Flags().StringP("format", "f", "dot", "output format (dot, mermaid, graphml or svg)")
+	rootCmd.AddCommand(graphCmd)
+}
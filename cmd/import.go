@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import file.car",
+	Short: "load the blocks of a CAR archive and optionally commit a root as a version",
+	Args:  cobra.ExactArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		commit, err := cmd.Flags().GetBool("commit")
+		if err != nil {
+			return err
+		}
+		root, err := cmd.Flags().GetString("root")
+		if err != nil {
+			return err
+		}
+		message, err := cmd.Flags().GetString("message")
+		if err != nil {
+			return err
+		}
+		nopin, err := cmd.Flags().GetBool("no-pin")
+		if err != nil {
+			return err
+		}
+		parents, err := cmd.Flags().GetStringSlice("parents")
+		if err != nil {
+			return err
+		}
+		pick, err := cmd.Flags().GetBool("pick")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		handle, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer handle.Close()
+
+		imported, err := dorothy.ImportCar(handle, nopin)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("imported %d block(s)\n", imported.Blocks)
+		for _, r := range imported.Roots {
+			if r.IsComplete() {
+				fmt.Printf("complete %s\n", r.Hash)
+			} else {
+				fmt.Printf("incomplete %s: %d block(s) missing\n", r.Hash, len(r.Missing))
+			}
+		}
+
+		commit = commit || root != "" || message != "" || len(parents) != 0 || pick
+		if !commit {
+			return nil
+		}
+
+		selected, err := imported.Root(root)
+		if err != nil {
+			return err
+		} else if !selected.IsComplete() {
+			return fmt.Errorf("cannot commit %s: %d block(s) missing", selected.Hash, len(selected.Missing))
+		}
+
+		parents, ok, err := checkParentage(dorothy, parents, pick)
+		if err != nil {
+			return fmt.Errorf("%v; aborting import\n", err)
+		} else if !ok {
+			return nil
+		}
+
+		if message == "" {
+			message, err = dorothy.ReadFromEditor("commit-msg")
+			if err != nil {
+				return fmt.Errorf("%v; aborting import\n", err)
+			}
+		}
+
+		conflicts, err := dorothy.CommitImported(selected, message, parents)
+		if len(conflicts) != 0 {
+			fmt.Fprintf(os.Stderr, "conflicts:\n")
+			for _, conflict := range conflicts {
+				fmt.Fprintf(os.Stderr, "  %s", conflict)
+			}
+		}
+		return err
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().Bool("commit", false, "create a version from the root of the archive (implied by --root, --message, --parents and --pick)")
+	importCmd.Flags().StringP("root", "r", "", "root to commit if the archive has more than one")
+	importCmd.Flags().StringP("message", "m", "", "commit message")
+	importCmd.Flags().BoolP("no-pin", "N", false, "do not pin the imported roots to your local node")
+	importCmd.Flags().StringSliceP("parents", "p", nil, "parents of the new version")
+	importCmd.Flags().BoolP("pick", "P", false, "interactively choose parents (implied by empty --parents)")
+}
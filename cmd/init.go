@@ -23,6 +23,10 @@ var initCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		shared, err := cmd.Flags().GetBool("shared")
+		if err != nil {
+			return err
+		}
 
 		dorothy, err := core.NewDorothy()
 		if err != nil {
@@ -41,7 +45,7 @@ var initCmd = &cobra.Command{
 		}
 
 		initialized := dorothy.IsInitialized()
-		if err = dorothy.Initialize(global); err != nil {
+		if err = dorothy.Initialize(global, shared); err != nil {
 			return err
 		}
 
@@ -57,5 +61,6 @@ var initCmd = &cobra.Command{
 
 func init() {
 	initCmd.Flags().BoolP("global", "g", false, "initialize the repository to use a global IPFS instance")
+	initCmd.Flags().BoolP("shared", "s", false, "initialize the repository to use the per-user shared IPFS repo")
 	rootCmd.AddCommand(initCmd)
 }
@@ -9,13 +9,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func printCommit(version *core.Version, showParents bool) {
+func formatCommit(version *core.Version, showParents bool) string {
 	s := strings.Builder{}
 	t := tabwriter.NewWriter(&s, 0, 4, 2, ' ', 0)
 	fmt.Fprintf(t, "%s\t%s\n", "Hash:", version.Hash)
 	fmt.Fprintf(t, "%s\t%s\n", "Author:", version.Author)
 	fmt.Fprintf(t, "%s\t%s\n", "Date:", version.Date.Format("Mon Jan 02 15:04:05 2006 -0700"))
 	fmt.Fprintf(t, "%s\t%s\n", "Type:", version.PathType.String())
+	if version.Summary != nil {
+		fmt.Fprintf(t, "%s\t%s\n", "Size:", version.Summary)
+	}
 	if showParents {
 		for i, parent := range version.Parents {
 			if i == 0 {
@@ -27,7 +30,37 @@ func printCommit(version *core.Version, showParents bool) {
 	}
 	t.Flush()
 
-	fmt.Printf("%s\n    %s\n\n", s.String(), version.Message)
+	return fmt.Sprintf("%s\n    %s\n", s.String(), version.Message)
+}
+
+func printCommit(version *core.Version, showParents bool) {
+	fmt.Printf("%s\n", formatCommit(version, showParents))
+}
+
+func printGraph(manifest *core.Manifest) {
+	for _, entry := range manifest.Graph() {
+		for _, row := range entry.Before {
+			fmt.Println(row)
+		}
+
+		prefixes := append([]string{entry.Node}, entry.After...)
+		width := len(entry.Padding)
+		for _, prefix := range prefixes {
+			width = max(width, len(prefix))
+		}
+
+		lines := strings.Split(formatCommit(entry.Version, len(entry.Version.Parents) > 1), "\n")
+		for i, line := range lines {
+			prefix := entry.Padding
+			if i < len(prefixes) {
+				prefix = prefixes[i]
+			}
+			fmt.Println(strings.TrimRight(fmt.Sprintf("%-*s %s", width, prefix, line), " "))
+		}
+		for i := len(lines); i < len(prefixes); i++ {
+			fmt.Println(prefixes[i])
+		}
+	}
 }
 
 var logCmd = &cobra.Command{
@@ -42,6 +75,10 @@ var logCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		graph, err := cmd.Flags().GetBool("graph")
+		if err != nil {
+			return err
+		}
 		dorothy, err := core.NewDorothy()
 		if err != nil {
 			return err
@@ -68,6 +105,8 @@ var logCmd = &cobra.Command{
 		}
 		if len(versions) == 0 {
 			fmt.Printf("no versions")
+		} else if graph {
+			printGraph(dorothy.Manifest)
 		} else {
 			for i := len(versions) - 1; i >= 0; i-- {
 				version := versions[i]
@@ -81,5 +120,6 @@ var logCmd = &cobra.Command{
 }
 
 func init() {
+	logCmd.Flags().BoolP("graph", "g", false, "draw the version graph alongside the log")
 	rootCmd.AddCommand(logCmd)
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls rev[:path]",
+	Short: "list the contents of a version",
+	Args:  cobra.ExactArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		recursive, err := cmd.Flags().GetBool("recursive")
+		if err != nil {
+			return err
+		}
+		asJson, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		entries, err := dorothy.Ls(args[0], recursive)
+		if err != nil {
+			return err
+		}
+
+		if asJson {
+			if entries == nil {
+				entries = []core.TreeEntry{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(entries)
+		}
+
+		t := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, entry := range entries {
+			name := entry.Path
+			if entry.IsDir() {
+				name += "/"
+			}
+			fmt.Fprintf(t, "%s\t%d\t%s\n", entry.Cid, entry.Size, name)
+		}
+		return t.Flush()
+	}),
+}
+
+func init() {
+	lsCmd.Flags().BoolP("recursive", "r", false, "list subdirectories recursively")
+	lsCmd.Flags().Bool("json", false, "output entries as JSON")
+	rootCmd.AddCommand(lsCmd)
+}
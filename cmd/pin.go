@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin <rev>",
+	Short: "pin a version so that gc keeps its content",
+	Args:  cobra.ExactArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		tag, err := cmd.Flags().GetString("tag")
+		if err != nil {
+			return err
+		}
+		remote, err := cmd.Flags().GetString("remote")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		if remote != "" {
+			pin, err := dorothy.PinRemote(args[0], remote)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("requested pin of %s on %s: %s\n", pin.Hash, pin.Service, pin.Status)
+			return nil
+		}
+
+		version, err := dorothy.Pin(args[0], tag)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("pinned %s\n", version.Hash)
+		return nil
+	}),
+}
+
+func init() {
+	pinCmd.Flags().StringP("tag", "t", "", "tag the version so that retention.keep_tagged keeps it")
+	pinCmd.Flags().StringP("remote", "r", "", "pin the version to the named pinning service instead")
+	rootCmd.AddCommand(pinCmd)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var pinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "list pinned versions and their sizes",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+		remote, err := cmd.Flags().GetBool("remote")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		if remote {
+			return printRemotePins(dorothy)
+		}
+
+		pins, err := dorothy.Pins()
+		if err != nil {
+			return err
+		}
+
+		if len(pins) == 0 {
+			fmt.Println("no pinned versions")
+			return nil
+		}
+
+		var total uint64
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, pin := range pins {
+			total += pin.Size
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%s\n",
+				pin.Hash,
+				pin.Date.Format("2006-01-02 15:04:05"),
+				core.FormatBytes(pin.Size),
+				strings.Join(pin.Tags, ", "),
+			)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("%d pinned version(s), %s\n", len(pins), core.FormatBytes(total))
+		return nil
+	}),
+}
+
+func printRemotePins(dorothy *core.Dorothy) error {
+	pins, err := dorothy.RemotePinStatus(true)
+	if len(pins) == 0 && err == nil {
+		fmt.Println("no remote pins")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, pin := range pins {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pin.Hash, pin.Service, pin.Status, pin.Updated.Format("2006-01-02 15:04:05"))
+	}
+	if ferr := w.Flush(); ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+func init() {
+	pinsCmd.Flags().BoolP("remote", "r", false, "list requests to pinning services and their status")
+	rootCmd.AddCommand(pinsCmd)
+}
@@ -38,6 +38,11 @@ var pushCmd = &cobra.Command{
 			}
 		}
 
+		strict, err := cmd.Flags().GetBool("strict")
+		if err != nil {
+			return err
+		}
+
 		if err := dorothy.Setup(); err != nil {
 			return err
 		}
@@ -56,10 +61,21 @@ var pushCmd = &cobra.Command{
 			return fmt.Errorf("push failed\n")
 		}
 
+		pins, err := dorothy.PinPushedVersions()
+		for _, pin := range pins {
+			fmt.Printf("requested pin of %s on %s: %s\n", pin.Hash, pin.Service, pin.Status)
+		}
+		if err != nil && strict {
+			return fmt.Errorf("remote pinning failed - %v", err)
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: versions were pushed but remote pinning failed - %v\n", err)
+		}
+
 		return nil
 	}),
 }
 
 func init() {
+	pushCmd.Flags().Bool("strict", false, "fail if the pushed versions cannot be pinned on the remote pinning services")
 	rootCmd.AddCommand(pushCmd)
 }
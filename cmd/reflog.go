@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var reflogCmd = &cobra.Command{
+	Use:   "reflog",
+	Short: "list changes to the manifest, newest first",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if !dorothy.IsInitialized() {
+			return fmt.Errorf("not a dorothy repository")
+		}
+
+		entries, err := dorothy.Reflog()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			summary := entry.Operation
+			if message, _, _ := strings.Cut(entry.Message, "\n"); message != "" {
+				summary += ": " + message
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entry.New, entry.Date.Format("Mon Jan 02 15:04:05 2006 -0700"), summary)
+		}
+		return w.Flush()
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(reflogCmd)
+}
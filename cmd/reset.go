@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset --manifest hash",
+	Short: "point the repository at an earlier manifest",
+	Args:  cobra.NoArgs,
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		hash, err := cmd.Flags().GetString("manifest")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(core.IpfsOffline); err != nil {
+			return err
+		}
+
+		if err := dorothy.ResetManifest(hash, core.ReflogReset, ""); err != nil {
+			return err
+		}
+
+		fmt.Printf("manifest is now %s\n", dorothy.Manifest.Hash)
+		return nil
+	}),
+}
+
+func init() {
+	resetCmd.Flags().String("manifest", "", "manifest hash, or a prefix of one listed by dorothy reflog")
+	resetCmd.MarkFlagRequired("manifest")
+	rootCmd.AddCommand(resetCmd)
+}
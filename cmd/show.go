@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show rev",
+	Short: "show a version's metadata and contents summary",
+	Args:  cobra.ExactArgs(1),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		version, summary, err := dorothy.Summarize(args[0])
+		if err != nil {
+			return err
+		}
+
+		s := strings.Builder{}
+		t := tabwriter.NewWriter(&s, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(t, "%s\t%s\n", "Hash:", version.Hash)
+		fmt.Fprintf(t, "%s\t%s\n", "Author:", version.Author)
+		fmt.Fprintf(t, "%s\t%s\n", "Date:", version.Date.Format("Mon Jan 02 15:04:05 2006 -0700"))
+		fmt.Fprintf(t, "%s\t%s\n", "Type:", version.PathType.String())
+		for i, parent := range version.Parents {
+			if i == 0 {
+				fmt.Fprintf(t, "%s\t%s\n", "Parents:", parent)
+			} else {
+				fmt.Fprintf(t, "%s\t%s\n", "", parent)
+			}
+		}
+		fmt.Fprintf(t, "%s\t%d\n", "Files:", summary.Files)
+		fmt.Fprintf(t, "%s\t%d\n", "Directories:", summary.Directories)
+		fmt.Fprintf(t, "%s\t%s (%d bytes)\n", "Size:", core.FormatBytes(summary.Bytes), summary.Bytes)
+		t.Flush()
+
+		fmt.Printf("%s\n    %s\n", s.String(), version.Message)
+
+		return nil
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/39alpha/dorothy/core"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <dir> [<rev>]",
+	Short: "check that a local directory matches a version",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: HandleErrors(func(cmd *cobra.Command, args []string) error {
+		configpath, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		noinherit, err := cmd.Flags().GetBool("noinherit")
+		if err != nil {
+			return err
+		}
+
+		dorothy, err := core.NewDorothy()
+		if err != nil {
+			return err
+		}
+
+		if noinherit {
+			if err := dorothy.ResetConfig(); err != nil {
+				return err
+			}
+		}
+
+		if configpath != "" {
+			if err := dorothy.LoadConfigFile(configpath); err != nil {
+				return err
+			}
+		}
+
+		if err := dorothy.Setup(); err != nil {
+			return err
+		}
+
+		var rev string
+		if len(args) == 2 {
+			rev = args[1]
+		}
+		version, diff, err := dorothy.Verify(args[0], rev)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range diff {
+			fmt.Printf("%-8s  %s\n", entry.Type, entry.Path)
+		}
+		if len(diff) != 0 {
+			return fmt.Errorf("%q differs from version %s in %d file(s)", args[0], version.Hash, len(diff))
+		}
+
+		fmt.Printf("%q matches version %s\n", args[0], version.Hash)
+		return nil
+	}),
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/core/coreiface/options"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+const (
+	LayoutBalanced = "balanced"
+	LayoutTrickle  = "trickle"
+)
+
+// AddSettings records how the files of a version were added to IPFS, so that
+// a local file can be hashed the same way to check it against the version.
+type AddSettings struct {
+	CidVersion int    `json:"cid_version"`
+	RawLeaves  bool   `json:"raw_leaves,omitempty"`
+	Chunker    string `json:"chunker"`
+	Hash       string `json:"hash"`
+	Layout     string `json:"layout"`
+}
+
+// DefaultAddSettings are the settings with which versions are committed.
+// Committing with nocopy needs raw leaves, since the leaves then refer to the
+// files in place.
+func DefaultAddSettings(nocopy bool) *AddSettings {
+	return &AddSettings{
+		CidVersion: 0,
+		RawLeaves:  nocopy,
+		Chunker:    "size-262144",
+		Hash:       "sha2-256",
+		Layout:     LayoutBalanced,
+	}
+}
+
+func (a *AddSettings) options() ([]options.UnixfsAddOption, error) {
+	hash, ok := mh.Names[a.Hash]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash function %q", a.Hash)
+	}
+
+	layout := options.BalancedLayout
+	switch a.Layout {
+	case LayoutBalanced:
+	case LayoutTrickle:
+		layout = options.TrickleLayout
+	default:
+		return nil, fmt.Errorf("unknown layout %q", a.Layout)
+	}
+
+	return []options.UnixfsAddOption{
+		options.Unixfs.CidVersion(a.CidVersion),
+		options.Unixfs.RawLeaves(a.RawLeaves),
+		options.Unixfs.Chunker(a.Chunker),
+		options.Unixfs.Hash(hash),
+		options.Unixfs.Layout(layout),
+	}, nil
+}
+
+// inferAddSettings guesses the settings a file was added with from its CID
+// and its root block, for versions which were committed before the settings
+// were recorded. The chunker and layout cannot be recovered, so the defaults
+// are assumed.
+func (s *Ipfs) inferAddSettings(ctx context.Context, c cid.Cid) (*AddSettings, error) {
+	settings := DefaultAddSettings(false)
+	settings.CidVersion = int(c.Version())
+	if name, ok := mh.Codes[c.Prefix().MhType]; ok {
+		settings.Hash = name
+	} else {
+		return nil, fmt.Errorf("%s uses an unknown hash function", c)
+	}
+
+	if c.Type() == cid.Raw {
+		settings.RawLeaves = true
+		return settings, nil
+	}
+
+	node, err := s.Dag().Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	links := node.Links()
+	settings.RawLeaves = len(links) != 0 && links[0].Cid.Type() == cid.Raw
+	return settings, nil
+}
@@ -0,0 +1,513 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+const (
+	BagItVersion = "1.0"
+	// BagVersionFile is the tag file which records the version a bag holds.
+	BagVersionFile = "dorothy-version.txt"
+)
+
+// bagAlgorithms are the checksum algorithms with which bags are written and
+// which they can be validated with.
+var bagAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+type BagProblem struct {
+	// Path is the file in the bag, relative to its root, that the problem
+	// concerns.
+	Path   string
+	Detail string
+}
+
+func (p BagProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.Path, p.Detail)
+}
+
+// encodeBagPath percent-encodes the characters which cannot appear in paths
+// in BagIt manifests.
+func encodeBagPath(p string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(p)
+}
+
+func decodeBagPath(p string) string {
+	return strings.NewReplacer("%0D", "\r", "%0d", "\r", "%0A", "\n", "%0a", "\n", "%25", "%").Replace(p)
+}
+
+// writeTagFile writes the labels and values of a tag file such as
+// bag-info.txt, continuing multi-line values on indented lines.
+func writeTagFile(filename string, tags [][2]string) error {
+	var b strings.Builder
+	for _, tag := range tags {
+		lines := strings.Split(strings.TrimRight(tag[1], "\n"), "\n")
+		fmt.Fprintf(&b, "%s: %s\n", tag[0], lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return os.WriteFile(filename, []byte(b.String()), 0644)
+}
+
+func readTagFile(filename string) (map[string][]string, error) {
+	handle, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+
+	tags := make(map[string][]string)
+	var label string
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		} else if (line[0] == ' ' || line[0] == '\t') && label != "" {
+			values := tags[label]
+			values[len(values)-1] += "\n" + strings.TrimSpace(line)
+			continue
+		}
+
+		l, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		label = strings.TrimSpace(l)
+		tags[label] = append(tags[label], strings.TrimSpace(value))
+	}
+	return tags, scanner.Err()
+}
+
+// checksumFiles computes the checksum of each of the files, given relative to
+// dir, with each of the algorithms.
+func checksumFiles(dir string, filenames []string, algorithms map[string]func() hash.Hash) (map[string]map[string]string, error) {
+	sums := make(map[string]map[string]string)
+	if len(algorithms) == 0 {
+		return sums, nil
+	}
+	for algorithm := range algorithms {
+		sums[algorithm] = make(map[string]string)
+	}
+
+	for _, filename := range filenames {
+		handle, err := os.Open(filepath.Join(dir, filepath.FromSlash(filename)))
+		if err != nil {
+			return nil, err
+		}
+
+		hashes := make(map[string]hash.Hash)
+		var writers []io.Writer
+		for algorithm, h := range algorithms {
+			hashes[algorithm] = h()
+			writers = append(writers, hashes[algorithm])
+		}
+		_, err = io.Copy(io.MultiWriter(writers...), handle)
+		handle.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for algorithm, h := range hashes {
+			sums[algorithm][filename] = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	return sums, nil
+}
+
+// payloadFiles lists the files under the payload directory of a bag, relative
+// to the bag, along with their total size.
+func payloadFiles(dir string) ([]string, int64, error) {
+	var filenames []string
+	var size int64
+	err := filepath.WalkDir(filepath.Join(dir, "data"), func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if entry.IsDir() {
+			return nil
+		} else if !entry.Type().IsRegular() {
+			return fmt.Errorf("%q: bags can only hold regular files", p)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		filenames = append(filenames, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(filenames)
+	return filenames, size, err
+}
+
+// writeBagManifests writes a manifest of the files for each algorithm.
+// Checksums in known, keyed by algorithm and then filename, are used as they
+// are; only the algorithms which do not cover every file are computed.
+func writeBagManifests(dir, prefix string, filenames []string, known map[string]map[string]string) error {
+	compute := make(map[string]func() hash.Hash)
+	for algorithm, h := range bagAlgorithms {
+		for _, filename := range filenames {
+			if _, ok := known[algorithm][filename]; !ok {
+				compute[algorithm] = h
+				break
+			}
+		}
+	}
+
+	sums, err := checksumFiles(dir, filenames, compute)
+	if err != nil {
+		return err
+	}
+	for algorithm := range bagAlgorithms {
+		if _, ok := compute[algorithm]; !ok {
+			sums[algorithm] = known[algorithm]
+		}
+	}
+
+	for algorithm, checksums := range sums {
+		var b strings.Builder
+		for _, filename := range filenames {
+			fmt.Fprintf(&b, "%s  %s\n", checksums[filename], encodeBagPath(filename))
+		}
+		name := filepath.Join(dir, prefix+"-"+algorithm+".txt")
+		if err := os.WriteFile(name, []byte(b.String()), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteBag writes a version as a BagIt bag at dir, which must not exist or be
+// empty. The payload is checked out to the data directory; the version's
+// metadata goes in bag-info.txt and its hash in dorothy-version.txt.
+func (d *Dorothy) WriteBag(rev, dir string) (*Version, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) != 0 {
+		return nil, fmt.Errorf("%q already exists and is not empty", dir)
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	payload := filepath.Join(dir, "data")
+	if version.PathType == PathTypeFile {
+		if err := os.MkdirAll(payload, 0755); err != nil {
+			return nil, err
+		}
+		payload = filepath.Join(payload, version.Hash)
+	}
+	if err := d.Ipfs.Checkout(d, version.Hash, "", payload, NewCheckoutSettings()); err != nil {
+		return nil, err
+	}
+
+	filenames, size, err := payloadFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	// The sha256 checksums recorded at commit are reused rather than read
+	// from the payload again.
+	var known map[string]map[string]string
+	if version.Checksums != "" {
+		checksums, err := d.Ipfs.GetChecksums(d, version.Checksums)
+		if err != nil {
+			return nil, err
+		}
+		sha256s := make(map[string]string)
+		for _, file := range checksums.Files {
+			sha256s["data/"+file.Path] = file.Sha256
+		}
+		known = map[string]map[string]string{"sha256": sha256s}
+	}
+	if err := writeBagManifests(dir, "manifest", filenames, known); err != nil {
+		return nil, err
+	}
+
+	err = writeTagFile(filepath.Join(dir, "bagit.txt"), [][2]string{
+		{"BagIt-Version", BagItVersion},
+		{"Tag-File-Character-Encoding", "UTF-8"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info := [][2]string{}
+	if d.Config.Remote != nil && d.Config.Remote.Organization != "" {
+		info = append(info, [2]string{"Source-Organization", d.Config.Remote.Organization})
+	}
+	if author, err := mail.ParseAddress(version.Author); err == nil {
+		if author.Name != "" {
+			info = append(info, [2]string{"Contact-Name", author.Name})
+		}
+		info = append(info, [2]string{"Contact-Email", author.Address})
+	} else if version.Author != "" {
+		info = append(info, [2]string{"Contact-Name", version.Author})
+	}
+	if version.Message != "" {
+		info = append(info, [2]string{"External-Description", version.Message})
+	}
+	info = append(info,
+		[2]string{"External-Identifier", version.Hash},
+		[2]string{"Bagging-Date", time.Now().Format(time.DateOnly)},
+		[2]string{"Bag-Software-Agent", "dorothy"},
+		[2]string{"Payload-Oxum", fmt.Sprintf("%d.%d", size, len(filenames))},
+	)
+	if err := writeTagFile(filepath.Join(dir, "bag-info.txt"), info); err != nil {
+		return nil, err
+	}
+
+	id, err := cid.Decode(version.Hash)
+	if err != nil {
+		return nil, err
+	}
+	tags := [][2]string{
+		{"Dorothy-Version", version.Hash},
+		{"Dorothy-Manifest", d.Manifest.Hash},
+		{"Payload-CID", cid.NewCidV1(id.Type(), id.Hash()).String()},
+		{"Path-Type", version.PathType.String()},
+		{"Date", version.Date.Format(time.RFC3339)},
+	}
+	for _, parent := range version.Parents {
+		tags = append(tags, [2]string{"Parent", parent})
+	}
+	if err := writeTagFile(filepath.Join(dir, BagVersionFile), tags); err != nil {
+		return nil, err
+	}
+
+	tagFiles := []string{"bag-info.txt", "bagit.txt", BagVersionFile}
+	for algorithm := range bagAlgorithms {
+		tagFiles = append(tagFiles, "manifest-"+algorithm+".txt")
+	}
+	sort.Strings(tagFiles)
+	return version, writeBagManifests(dir, "tagmanifest", tagFiles, nil)
+}
+
+// readBagManifest parses a payload or tag manifest into a map from paths to
+// checksums.
+func readBagManifest(filename string) (map[string]string, error) {
+	handle, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		checksum, p, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		p = filepath.ToSlash(decodeBagPath(strings.TrimLeft(p, " *")))
+		checksums[p] = strings.ToLower(checksum)
+	}
+	return checksums, scanner.Err()
+}
+
+// ValidateBag checks that the bag at dir is complete and that every file in
+// it matches the checksums in its manifests. Only sha256 and sha512
+// manifests can be checked.
+func ValidateBag(dir string) ([]BagProblem, error) {
+	var problems []BagProblem
+	add := func(p, format string, args ...any) {
+		problems = append(problems, BagProblem{Path: p, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	declaration, err := readTagFile(filepath.Join(dir, "bagit.txt"))
+	if err != nil {
+		add("bagit.txt", "cannot read bag declaration: %v", err)
+		return problems, nil
+	} else if len(declaration["BagIt-Version"]) == 0 || len(declaration["Tag-File-Character-Encoding"]) == 0 {
+		add("bagit.txt", "bag declaration is incomplete")
+	}
+
+	payload, size, err := payloadFiles(dir)
+	if err != nil {
+		add("data", "%v", err)
+		return problems, nil
+	}
+
+	manifests, err := filepath.Glob(filepath.Join(dir, "manifest-*.txt"))
+	if err != nil {
+		return nil, err
+	} else if len(manifests) == 0 {
+		add("manifest-sha256.txt", "bag has no payload manifest")
+	}
+	tagManifests, err := filepath.Glob(filepath.Join(dir, "tagmanifest-*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, manifest := range append(manifests, tagManifests...) {
+		name := filepath.Base(manifest)
+		_, algorithm, _ := strings.Cut(strings.TrimSuffix(name, ".txt"), "-")
+		if _, ok := bagAlgorithms[algorithm]; !ok {
+			add(name, "unsupported checksum algorithm %q", algorithm)
+			continue
+		}
+
+		checksums, err := readBagManifest(manifest)
+		if err != nil {
+			add(name, "%v", err)
+			continue
+		}
+
+		var paths []string
+		for p := range checksums {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		var listed []string
+		for _, p := range paths {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(p))); err != nil {
+				add(p, "listed in %s but missing", name)
+			} else {
+				listed = append(listed, p)
+			}
+		}
+		if strings.HasPrefix(name, "manifest-") {
+			for _, p := range payload {
+				if _, ok := checksums[p]; !ok {
+					add(p, "not listed in %s", name)
+				}
+			}
+		}
+
+		sums, err := checksumFiles(dir, listed, map[string]func() hash.Hash{algorithm: bagAlgorithms[algorithm]})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range listed {
+			if sums[algorithm][p] != checksums[p] {
+				add(p, "%s checksum does not match %s", algorithm, name)
+			}
+		}
+	}
+
+	info, err := readTagFile(filepath.Join(dir, "bag-info.txt"))
+	if err != nil && !os.IsNotExist(err) {
+		add("bag-info.txt", "%v", err)
+	} else if oxum := info["Payload-Oxum"]; len(oxum) != 0 {
+		if actual := fmt.Sprintf("%d.%d", size, len(payload)); oxum[0] != actual {
+			add("bag-info.txt", "Payload-Oxum is %s but the payload is %s", oxum[0], actual)
+		}
+	}
+
+	return problems, nil
+}
+
+// ValidateBagVersion validates the bag at dir and checks that its payload is
+// exactly the content of the version given by rev, or of the version recorded
+// in the bag if rev is empty.
+func (d *Dorothy) ValidateBagVersion(dir, rev string) (*Version, []BagProblem, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	problems, err := ValidateBag(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags, err := readTagFile(filepath.Join(dir, BagVersionFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	recorded := tags["Dorothy-Version"]
+	if rev == "" {
+		if len(recorded) == 0 {
+			return nil, nil, fmt.Errorf("bag does not record a version; specify one")
+		}
+		rev = recorded[0]
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(recorded) != 0 && recorded[0] != version.Hash {
+		problems = append(problems, BagProblem{
+			Path:   BagVersionFile,
+			Detail: fmt.Sprintf("bag records version %s", recorded[0]),
+		})
+	}
+
+	payload, _, err := payloadFiles(dir)
+	if err != nil {
+		return version, problems, nil
+	}
+	unexpected := make(map[string]bool)
+	for _, p := range payload {
+		unexpected[p] = true
+	}
+
+	entries, err := d.Ipfs.Ls(d, version.Hash, "", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		p := "data/" + entry.Path
+		if !unexpected[p] {
+			problems = append(problems, BagProblem{Path: p, Detail: "missing from the payload"})
+			continue
+		}
+		delete(unexpected, p)
+
+		matches, err := d.Ipfs.matchesCid(d, filepath.Join(dir, filepath.FromSlash(p)), entry.Cid, version.Add)
+		if err != nil {
+			return nil, nil, err
+		} else if !matches {
+			problems = append(problems, BagProblem{Path: p, Detail: "differs from the version"})
+		}
+	}
+
+	var extra []string
+	for p := range unexpected {
+		extra = append(extra, p)
+	}
+	sort.Strings(extra)
+	for _, p := range extra {
+		problems = append(problems, BagProblem{Path: p, Detail: "not part of the version"})
+	}
+
+	return version, problems, nil
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBagPaths(t *testing.T) {
+	for _, p := range []string{"data/a.txt", "data/100%.txt", "data/line\nbreak", "data/%0A"} {
+		if decoded := decodeBagPath(encodeBagPath(p)); decoded != p {
+			t.Errorf("expected %q to round trip, got %q", p, decoded)
+		}
+	}
+	if encoded := encodeBagPath("data/a\r\nb"); encoded != "data/a%0D%0Ab" {
+		t.Errorf("unexpected encoding %q", encoded)
+	}
+}
+
+func TestTagFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "bag-info.txt")
+	err := writeTagFile(filename, [][2]string{
+		{"External-Description", "first line\n\nmore detail\n"},
+		{"Parent", "a"},
+		{"Parent", "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := readTagFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tags["External-Description"]; len(got) != 1 || got[0] != "first line\nmore detail" {
+		t.Errorf("unexpected description %q", got)
+	}
+	if got := tags["Parent"]; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("expected repeated labels to be kept, got %q", got)
+	}
+}
+
+func TestBag(t *testing.T) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.txt": "a", "sub/b.txt": "bb"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, err := client.Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.Add(ctx, filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := client.SaveManifest(ctx, &Manifest{Versions: []*Version{
+		{Author: "Jane Doe <jane@example.org>", Date: time.Now(), Message: "data\n\nfrom the field", Hash: first, PathType: PathTypeDirectory},
+		{Date: time.Now(), Hash: second, PathType: PathTypeFile, Parents: []string{first}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Dorothy{Context: ctx, Ipfs: *client, Manifest: manifest}
+
+	bag := filepath.Join(t.TempDir(), "bag")
+	if _, err := d.WriteBag(first, bag); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.WriteBag(first, bag); err == nil {
+		t.Errorf("expected an existing bag not to be overwritten")
+	}
+
+	info, err := readTagFile(filepath.Join(bag, "bag-info.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info["Contact-Email"]; len(got) != 1 || got[0] != "jane@example.org" {
+		t.Errorf("unexpected contact %q", got)
+	}
+	if got := info["Payload-Oxum"]; len(got) != 1 || got[0] != "3.2" {
+		t.Errorf("unexpected Payload-Oxum %q", got)
+	}
+
+	version, problems, err := d.ValidateBagVersion(bag, "")
+	if err != nil {
+		t.Fatal(err)
+	} else if version.Hash != first || len(problems) != 0 {
+		t.Errorf("expected a valid bag of %s, got %s with %v", first, version.Hash, problems)
+	}
+
+	if _, problems, err := d.ValidateBagVersion(bag, second); err != nil {
+		t.Fatal(err)
+	} else if len(problems) == 0 {
+		t.Errorf("expected the bag not to match another version")
+	}
+
+	if err := os.WriteFile(filepath.Join(bag, "data", "sub", "b.txt"), []byte("BB"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err = ValidateBag(bag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var details []string
+	for _, problem := range problems {
+		details = append(details, problem.String())
+	}
+	if len(problems) != 2 || !strings.Contains(details[0], "data/sub/b.txt") {
+		t.Errorf("expected the changed file to fail both manifests, got %v", details)
+	}
+
+	fileBag := filepath.Join(t.TempDir(), "file")
+	if _, err := d.WriteBag(second, fileBag); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(fileBag, "data", second)); err != nil {
+		t.Errorf("expected a file version to be bagged under its hash: %v", err)
+	}
+	if _, problems, err := d.ValidateBagVersion(fileBag, ""); err != nil {
+		t.Fatal(err)
+	} else if len(problems) != 0 {
+		t.Errorf("expected a valid bag, got %v", problems)
+	}
+}
+
+func TestBagRecordedChecksums(t *testing.T) {
+	client, d, hash := exportTestVersion(t)
+
+	// The recorded checksums are used as they are, so a bogus one shows up
+	// in the payload manifest while sha512 is still computed.
+	bogus := strings.Repeat("0", 64)
+	sidecar, err := client.SaveChecksums(d, &Checksums{Version: hash, Files: []FileChecksum{
+		{Path: "a.txt", Sha256: bogus},
+		{Path: "sub/b.txt", Sha256: bogus},
+	}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Manifest.Versions[0].Checksums = sidecar
+
+	bag := filepath.Join(t.TempDir(), "bag")
+	if _, err := d.WriteBag(hash, bag); err != nil {
+		t.Fatal(err)
+	}
+
+	sha256s, err := readBagManifest(filepath.Join(bag, "manifest-sha256.txt"))
+	if err != nil {
+		t.Fatal(err)
+	} else if sha256s["data/a.txt"] != bogus || sha256s["data/sub/b.txt"] != bogus {
+		t.Errorf("expected the recorded checksums, got %v", sha256s)
+	}
+
+	problems, err := ValidateBag(bag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		if strings.Contains(problem.String(), "sha512") {
+			t.Errorf("expected sha512 to be computed from the payload, got %v", problem)
+		}
+	}
+}
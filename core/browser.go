@@ -0,0 +1,536 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	detailStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			Padding(0, 1)
+
+	markedStyle = lipgloss.NewStyle().Bold(true)
+)
+
+type browserMode int
+
+const (
+	browseVersions browserMode = iota
+	browseFiles
+	browseDiff
+	browseCheckout
+	browseTag
+)
+
+type browserItem struct {
+	title       string
+	description string
+	filter      string
+}
+
+func (i browserItem) Title() string       { return i.title }
+func (i browserItem) Description() string { return i.description }
+func (i browserItem) FilterValue() string { return i.filter }
+
+type summaryMsg struct {
+	hash    string
+	summary TreeSummary
+	err     error
+}
+
+type filesMsg struct {
+	hash    string
+	entries []TreeEntry
+	err     error
+}
+
+type diffMsg struct {
+	from    string
+	to      string
+	entries []DiffEntry
+	err     error
+}
+
+type checkoutMsg struct {
+	hash string
+	dest string
+	err  error
+}
+
+type tagMsg struct {
+	hash string
+	tag  string
+	err  error
+}
+
+type browserKeyMap struct {
+	open     key.Binding
+	parent   key.Binding
+	child    key.Binding
+	mark     key.Binding
+	diff     key.Binding
+	checkout key.Binding
+	tag      key.Binding
+	copy     key.Binding
+	back     key.Binding
+	quit     key.Binding
+}
+
+func newBrowserKeyMap() *browserKeyMap {
+	return &browserKeyMap{
+		open: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "files"),
+		),
+		parent: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "parent"),
+		),
+		child: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "child"),
+		),
+		mark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark"),
+		),
+		diff: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "diff against marked"),
+		),
+		checkout: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "checkout"),
+		),
+		tag: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "pin and tag"),
+		),
+		copy: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy hash"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+	}
+}
+
+type browserModel struct {
+	dorothy   *Dorothy
+	mode      browserMode
+	keys      *browserKeyMap
+	versions  list.Model
+	pane      list.Model
+	input     textinput.Model
+	marked    *Version
+	summaries map[string]summaryMsg
+	width     int
+	height    int
+}
+
+func newBrowserModel(d *Dorothy, tags Tags) browserModel {
+	keys := newBrowserKeyMap()
+
+	versions := newVersionList("Versions", versionItems(d.Manifest, tags, false, nil), list.NewDefaultDelegate())
+	versions.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.open, keys.parent, keys.child, keys.quit}
+	}
+	versions.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.mark, keys.diff, keys.checkout, keys.tag, keys.copy}
+	}
+
+	pane := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	pane.Styles.Title = titleStyle
+	pane.DisableQuitKeybindings()
+	pane.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.back}
+	}
+
+	return browserModel{
+		dorothy:   d,
+		mode:      browseVersions,
+		keys:      keys,
+		versions:  versions,
+		pane:      pane,
+		input:     textinput.New(),
+		summaries: make(map[string]summaryMsg),
+	}
+}
+
+func (m browserModel) selectedItem() *version {
+	item, _ := m.versions.SelectedItem().(*version)
+	return item
+}
+
+func (m browserModel) selected() *Version {
+	if item := m.selectedItem(); item != nil {
+		return item.version
+	}
+	return nil
+}
+
+func (m browserModel) summarize() tea.Cmd {
+	version := m.selected()
+	if version == nil {
+		return nil
+	}
+	if _, ok := m.summaries[version.Hash]; ok {
+		return nil
+	}
+
+	d := m.dorothy
+	return func() tea.Msg {
+		summary, err := d.Ipfs.VersionSummary(d, version)
+		return summaryMsg{hash: version.Hash, summary: summary, err: err}
+	}
+}
+
+func (m *browserModel) jump(hash string) bool {
+	if m.versions.FilterState() != list.Unfiltered {
+		m.versions.ResetFilter()
+	}
+	for i, item := range m.versions.Items() {
+		if item.(*version).version.Hash == hash {
+			m.versions.Select(i)
+			return true
+		}
+	}
+	return false
+}
+
+func (m browserModel) Init() tea.Cmd {
+	return tea.Batch(tea.EnterAltScreen, m.summarize())
+}
+
+func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		m.width, m.height = msg.Width-h, msg.Height-v
+		m.versions.SetSize(m.width/2, m.height)
+		m.pane.SetSize(m.width, m.height)
+		return m, nil
+
+	case summaryMsg:
+		m.summaries[msg.hash] = msg
+		return m, nil
+
+	case filesMsg:
+		if msg.err != nil {
+			m.mode = browseVersions
+			return m, m.versions.NewStatusMessage(fmt.Sprintf("failed to list files: %v", msg.err))
+		}
+		var items []list.Item
+		for _, entry := range msg.entries {
+			name := entry.Path[strings.LastIndex(entry.Path, "/")+1:]
+			if entry.IsDir() {
+				name += "/"
+			}
+			items = append(items, browserItem{
+				title:       strings.Repeat("  ", entry.Depth()) + name,
+				description: fmt.Sprintf("%s%s  %s", strings.Repeat("  ", entry.Depth()), FormatBytes(entry.Size), entry.Cid),
+				filter:      entry.Path,
+			})
+		}
+		m.pane.Title = "Files in " + shortHash(msg.hash)
+		return m, m.pane.SetItems(items)
+
+	case diffMsg:
+		if msg.err != nil {
+			m.mode = browseVersions
+			return m, m.versions.NewStatusMessage(fmt.Sprintf("failed to diff versions: %v", msg.err))
+		}
+		var items []list.Item
+		for _, entry := range msg.entries {
+			path := entry.Path
+			if path == "" {
+				path = "(root)"
+			}
+			items = append(items, browserItem{
+				title:       path,
+				description: entry.Type,
+				filter:      path,
+			})
+		}
+		m.pane.Title = fmt.Sprintf("Changes from %s to %s", shortHash(msg.from), shortHash(msg.to))
+		if len(items) == 0 {
+			m.pane.Title += " (none)"
+		}
+		return m, m.pane.SetItems(items)
+
+	case checkoutMsg:
+		if msg.err != nil {
+			return m, m.versions.NewStatusMessage(fmt.Sprintf("checkout failed: %v", msg.err))
+		}
+		return m, m.versions.NewStatusMessage(fmt.Sprintf("checked out %s to %s", shortHash(msg.hash), msg.dest))
+
+	case tagMsg:
+		if msg.err != nil {
+			return m, m.versions.NewStatusMessage(fmt.Sprintf("tagging failed: %v", msg.err))
+		}
+		for _, item := range m.versions.Items() {
+			item := item.(*version)
+			item.tags = slices.DeleteFunc(item.tags, func(tag string) bool {
+				return tag == msg.tag
+			})
+			if item.version.Hash == msg.hash {
+				item.tags = append(item.tags, msg.tag)
+				sort.Strings(item.tags)
+			}
+		}
+		return m, m.versions.NewStatusMessage(fmt.Sprintf("pinned %s as %s", shortHash(msg.hash), msg.tag))
+
+	case tea.KeyMsg:
+		switch m.mode {
+		case browseCheckout, browseTag:
+			return m.updateInput(msg)
+		case browseFiles, browseDiff:
+			if m.pane.FilterState() != list.Filtering && key.Matches(msg, m.keys.back) {
+				m.mode = browseVersions
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.pane, cmd = m.pane.Update(msg)
+			return m, cmd
+		}
+
+		if m.versions.FilterState() == list.Filtering {
+			break
+		}
+
+		version := m.selected()
+		switch {
+		case key.Matches(msg, m.keys.quit):
+			return m, tea.Quit
+
+		case version == nil:
+			break
+
+		case key.Matches(msg, m.keys.open):
+			m.mode = browseFiles
+			m.pane.Title = "Loading files..."
+			d := m.dorothy
+			return m, tea.Batch(m.pane.SetItems(nil), func() tea.Msg {
+				entries, err := d.Ipfs.Ls(d, version.Hash, "", true)
+				return filesMsg{hash: version.Hash, entries: entries, err: err}
+			})
+
+		case key.Matches(msg, m.keys.parent):
+			for _, parent := range version.Parents {
+				if m.jump(parent) {
+					return m, m.summarize()
+				}
+			}
+			return m, m.versions.NewStatusMessage("no known parents")
+
+		case key.Matches(msg, m.keys.child):
+			children := m.dorothy.Manifest.Children(version.Hash)
+			if len(children) == 0 || !m.jump(children[0].Hash) {
+				return m, m.versions.NewStatusMessage("no children")
+			}
+			return m, m.summarize()
+
+		case key.Matches(msg, m.keys.mark):
+			m.marked = version
+			return m, m.versions.NewStatusMessage("marked " + shortHash(version.Hash))
+
+		case key.Matches(msg, m.keys.diff):
+			if m.marked == nil {
+				return m, m.versions.NewStatusMessage("mark a version to diff against first")
+			}
+			m.mode = browseDiff
+			m.pane.Title = "Loading changes..."
+			d, from := m.dorothy, m.marked
+			return m, tea.Batch(m.pane.SetItems(nil), func() tea.Msg {
+				entries, err := d.Ipfs.Diff(d, from.Hash, version.Hash)
+				return diffMsg{from: from.Hash, to: version.Hash, entries: entries, err: err}
+			})
+
+		case key.Matches(msg, m.keys.checkout):
+			m.mode = browseCheckout
+			m.input.Placeholder = "destination"
+			m.input.SetValue("")
+			return m, m.input.Focus()
+
+		case key.Matches(msg, m.keys.tag):
+			m.mode = browseTag
+			m.input.Placeholder = "tag"
+			m.input.SetValue("")
+			return m, m.input.Focus()
+
+		case key.Matches(msg, m.keys.copy):
+			if err := clipboard.WriteAll(version.Hash); err != nil {
+				return m, m.versions.NewStatusMessage("clipboard unavailable: " + version.Hash)
+			}
+			return m, m.versions.NewStatusMessage("copied " + shortHash(version.Hash))
+		}
+	}
+
+	switch m.mode {
+	case browseFiles, browseDiff:
+		var cmd tea.Cmd
+		m.pane, cmd = m.pane.Update(msg)
+		return m, cmd
+	case browseCheckout, browseTag:
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	before := m.selected()
+	var cmd tea.Cmd
+	m.versions, cmd = m.versions.Update(msg)
+	if after := m.selected(); after != before {
+		return m, tea.Batch(cmd, m.summarize())
+	}
+	return m, cmd
+}
+
+func (m browserModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = browseVersions
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		mode := m.mode
+		m.mode = browseVersions
+		m.input.Blur()
+		value := m.input.Value()
+		version := m.selected()
+		if value == "" || version == nil {
+			return m, nil
+		}
+		d := m.dorothy
+		if mode == browseTag {
+			return m, func() tea.Msg {
+				_, err := d.Pin(version.Hash, value)
+				return tagMsg{hash: version.Hash, tag: value, err: err}
+			}
+		}
+		dest := value
+		return m, tea.Batch(
+			m.versions.NewStatusMessage(fmt.Sprintf("checking out %s to %s...", shortHash(version.Hash), dest)),
+			func() tea.Msg {
+				return checkoutMsg{hash: version.Hash, dest: dest, err: d.Checkout(version.Hash, dest)}
+			},
+		)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m browserModel) details(version *Version) string {
+	if version == nil {
+		return "No version selected"
+	}
+
+	bold := lipgloss.NewStyle().Bold(true).Render
+
+	s := strings.Builder{}
+	t := tabwriter.NewWriter(&s, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(t, "%s\t%s\n", bold("Hash:"), version.Hash)
+	fmt.Fprintf(t, "%s\t%s\n", bold("Author:"), version.Author)
+	fmt.Fprintf(t, "%s\t%s\n", bold("Date:"), version.Date.Format(dateFormat))
+	fmt.Fprintf(t, "%s\t%s\n", bold("Type:"), version.PathType.String())
+
+	if summary, ok := m.summaries[version.Hash]; !ok {
+		fmt.Fprintf(t, "%s\t%s\n", bold("Size:"), "...")
+	} else if summary.err != nil {
+		fmt.Fprintf(t, "%s\t%s\n", bold("Size:"), "unavailable")
+	} else {
+		fmt.Fprintf(t, "%s\t%s\n", bold("Size:"), FormatBytes(summary.summary.Bytes))
+		fmt.Fprintf(t, "%s\t%d\n", bold("Files:"), summary.summary.Files)
+		fmt.Fprintf(t, "%s\t%d\n", bold("Directories:"), summary.summary.Directories)
+	}
+
+	for i, parent := range version.Parents {
+		label := ""
+		if i == 0 {
+			label = "Parents:"
+		}
+		fmt.Fprintf(t, "%s\t%s\n", bold(label), parent)
+	}
+	for i, child := range m.dorothy.Manifest.Children(version.Hash) {
+		label := ""
+		if i == 0 {
+			label = "Children:"
+		}
+		fmt.Fprintf(t, "%s\t%s\n", bold(label), child.Hash)
+	}
+	if item := m.selectedItem(); item != nil && len(item.tags) != 0 {
+		fmt.Fprintf(t, "%s\t%s\n", bold("Tags:"), strings.Join(item.tags, ", "))
+	}
+	if m.marked != nil {
+		fmt.Fprintf(t, "%s\t%s\n", bold("Marked:"), markedStyle.Render(m.marked.Hash))
+	}
+	t.Flush()
+
+	fmt.Fprintf(&s, "\n%s\n", version.Message)
+	return s.String()
+}
+
+func (m browserModel) View() string {
+	switch m.mode {
+	case browseFiles, browseDiff:
+		return appStyle.Render(m.pane.View())
+	}
+
+	left := m.versions.View()
+	right := m.details(m.selected())
+	if m.mode == browseCheckout {
+		right += "\n\nCheckout to:\n" + m.input.View()
+	} else if m.mode == browseTag {
+		right += "\n\nPin and tag as:\n" + m.input.View()
+	}
+	right = detailStyle.Width(max(m.width-lipgloss.Width(left)-2, 0)).Render(right)
+
+	return appStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, left, right))
+}
+
+func (d *Dorothy) Browse() error {
+	if d.Manifest.IsEmpty() {
+		return fmt.Errorf("no versions")
+	}
+
+	tags, err := d.LoadTags()
+	if err != nil {
+		return err
+	}
+
+	_, err = tea.NewProgram(newBrowserModel(d, tags)).Run()
+	return err
+}
+
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
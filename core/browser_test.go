@@ -0,0 +1,110 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func press(t *testing.T, m browserModel, keys string) browserModel {
+	t.Helper()
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(keys)})
+	return model.(browserModel)
+}
+
+func TestBrowserNavigatesParentsAndChildren(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"}, []string{"c", "b"})
+	m := newBrowserModel(&Dorothy{Manifest: manifest}, nil)
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = model.(browserModel)
+
+	if got := m.selected().Hash; got != "c" {
+		t.Fatalf("expected newest version c to be selected, got %q", got)
+	}
+
+	m = press(t, m, "p")
+	if got := m.selected().Hash; got != "b" {
+		t.Errorf("expected parent b to be selected, got %q", got)
+	}
+
+	m = press(t, m, "p")
+	if got := m.selected().Hash; got != "a" {
+		t.Errorf("expected parent a to be selected, got %q", got)
+	}
+
+	m = press(t, m, "c")
+	if got := m.selected().Hash; got != "b" {
+		t.Errorf("expected child b to be selected, got %q", got)
+	}
+}
+
+func TestBrowserMarksVersionForDiff(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"})
+	m := newBrowserModel(&Dorothy{Manifest: manifest}, nil)
+
+	m = press(t, m, "m")
+	if m.marked == nil || m.marked.Hash != "b" {
+		t.Fatalf("expected b to be marked, got %v", m.marked)
+	}
+
+	m = press(t, m, "p")
+	m = press(t, m, "d")
+	if m.mode != browseDiff {
+		t.Errorf("expected diff mode after diffing against the marked version")
+	}
+}
+
+func TestBrowserTagsVersion(t *testing.T) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := client.Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := &Manifest{Versions: []*Version{{Hash: hash, Message: "data", PathType: PathTypeDirectory}}}
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: manifest}
+	m := newBrowserModel(d, nil)
+
+	m = press(t, m, "t")
+	if m.mode != browseTag {
+		t.Fatalf("expected tag mode")
+	}
+	m = press(t, m, "release")
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(browserModel)
+	if cmd == nil {
+		t.Fatalf("expected a command to tag the version")
+	}
+	model, _ = m.Update(cmd())
+	m = model.(browserModel)
+
+	if item := m.selectedItem(); len(item.tags) != 1 || item.tags[0] != "release" {
+		t.Errorf("expected the version to show its tag, got %v", item.tags)
+	}
+	if tags, err := d.LoadTags(); err != nil || tags["release"] != hash {
+		t.Errorf("expected the tag to be saved, got %v, %v", tags, err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[uint64]string{
+		0:             "0 B",
+		1023:          "1023 B",
+		1024:          "1.0 KiB",
+		1536:          "1.5 KiB",
+		5 << 30:       "5.0 GiB",
+		2 * (1 << 40): "2.0 TiB",
+	}
+	for n, expected := range cases {
+		if got := FormatBytes(n); got != expected {
+			t.Errorf("FormatBytes(%d): expected %q, got %q", n, expected, got)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/core/coreiface/options"
+	"github.com/ipld/go-car"
+)
+
+// Bundle describes a CAR archive whose first root is a manifest and whose
+// other roots are the versions whose content it carries, along with their
+// checksums. Versions of the manifest which are not roots can be fetched
+// later over the network.
+type Bundle struct {
+	Manifest string
+	Versions []*Version
+}
+
+type BundleFetch struct {
+	Manifest string
+	// Versions records which versions the bundle carried and whether their
+	// content is now complete locally.
+	Versions []CarRoot
+	Blocks   int
+}
+
+// SelectVersions returns the versions named by specs in manifest order, or
+// every version if there are none. A spec is either a revision, or a range
+// a..b of the ancestors of b, inclusive, which are not ancestors of a.
+// Either end of a range may be omitted.
+func (d *Dorothy) SelectVersions(specs ...string) ([]*Version, error) {
+	if d.Manifest == nil {
+		return nil, fmt.Errorf("no manifest found")
+	} else if len(specs) == 0 {
+		return d.Manifest.Versions, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, spec := range specs {
+		from, to, isRange := strings.Cut(spec, "..")
+		if !isRange {
+			version, err := d.findVersion(spec)
+			if err != nil {
+				return nil, err
+			}
+			selected[version.Hash] = true
+			continue
+		}
+
+		included := make(map[string]bool)
+		if to == "" {
+			for _, version := range d.Manifest.Versions {
+				included[version.Hash] = true
+			}
+		} else {
+			version, err := d.findVersion(to)
+			if err != nil {
+				return nil, err
+			}
+			included = d.Manifest.Ancestors(version.Hash)
+		}
+
+		if from != "" {
+			version, err := d.findVersion(from)
+			if err != nil {
+				return nil, err
+			}
+			for hash := range d.Manifest.Ancestors(version.Hash) {
+				delete(included, hash)
+			}
+		}
+
+		for hash := range included {
+			selected[hash] = true
+		}
+	}
+
+	var versions []*Version
+	for _, version := range d.Manifest.Versions {
+		if selected[version.Hash] {
+			versions = append(versions, version)
+			delete(selected, version.Hash)
+		}
+	}
+	return versions, nil
+}
+
+// CreateBundle writes the manifest and the content of the versions selected
+// by specs to w. Only the local blockstore is consulted, so every selected
+// version must be complete locally.
+func (d *Dorothy) CreateBundle(w io.Writer, specs ...string) (*Bundle, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	versions, err := d.SelectVersions(specs...)
+	if err != nil {
+		return nil, err
+	}
+
+	offline, err := d.Ipfs.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := cid.Decode(d.Manifest.Hash)
+	if err != nil {
+		return nil, err
+	}
+	roots := []cid.Cid{manifest}
+	for _, version := range versions {
+		root, err := cid.Decode(version.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		missing, _, err := missingBlocks(d, offline, root)
+		if err != nil {
+			return nil, err
+		} else if len(missing) != 0 {
+			return nil, fmt.Errorf("version %s is missing %d block(s) locally; see `dorothy fsck --repair`", version.Hash, len(missing))
+		}
+		roots = append(roots, root)
+
+		// The checksums are small and let the receiver verify files
+		// without recomputing them, so they travel with the version.
+		if version.Checksums != "" {
+			checksums, err := cid.Decode(version.Checksums)
+			if err != nil {
+				return nil, err
+			}
+			roots = append(roots, checksums)
+		}
+	}
+
+	if err := car.WriteCar(d, offline.Dag(), roots, w); err != nil {
+		return nil, err
+	}
+	return &Bundle{Manifest: d.Manifest.Hash, Versions: versions}, nil
+}
+
+// FetchBundle loads a bundle into the blockstore and merges its manifest into
+// the repository's, as Fetch does for a remote. Only the versions it carries
+// are pinned; the others can be pinned later with `dorothy pin`.
+func (d *Dorothy) FetchBundle(r io.Reader, name string) (*BundleFetch, []Conflict, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	roots, blocks, err := d.Ipfs.ImportCar(d, r)
+	if err != nil {
+		return nil, nil, err
+	} else if len(roots) == 0 {
+		return nil, nil, fmt.Errorf("%s is not a dorothy bundle", name)
+	}
+
+	offline, err := d.Ipfs.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest, err := (&Ipfs{CoreAPI: offline}).GetManifest(d, roots[0].String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a dorothy bundle: %v", name, err)
+	}
+
+	checked, err := d.checkRoots(roots[1:], false)
+	if err != nil {
+		return nil, nil, err
+	}
+	fetched := &BundleFetch{Manifest: manifest.Hash, Blocks: blocks}
+	for _, root := range checked {
+		if _, err := manifest.FindVersion(root.Hash); err == nil {
+			fetched.Versions = append(fetched.Versions, root)
+		}
+	}
+
+	// Versions whose content the bundle did not carry are recorded without
+	// being pinned, since they cannot be fetched without a network.
+	nopin := make(map[string]bool)
+	for _, version := range manifest.Versions {
+		nopin[version.Hash] = true
+	}
+	for _, version := range fetched.Versions {
+		if version.IsComplete() {
+			delete(nopin, version.Hash)
+		}
+	}
+
+	conflicts, err := d.mergeManifest(manifest, ReflogFetch, name, nopin)
+	return fetched, conflicts, err
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSelectVersions(t *testing.T) {
+	// a <- b <- d, a <- c <- d
+	d := &Dorothy{Manifest: &Manifest{Versions: []*Version{
+		{Hash: "a"},
+		{Hash: "b", Parents: []string{"a"}},
+		{Hash: "c", Parents: []string{"a"}},
+		{Hash: "d", Parents: []string{"b", "c"}},
+		{Hash: "e"},
+	}}}
+
+	for _, test := range []struct {
+		specs    []string
+		expected string
+	}{
+		{nil, "abcde"},
+		{[]string{"b"}, "b"},
+		{[]string{"e", "b"}, "be"},
+		{[]string{"..d"}, "abcd"},
+		{[]string{"b..d"}, "cd"},
+		{[]string{"a.."}, "bcde"},
+		{[]string{"b..c", "e"}, "ce"},
+	} {
+		versions, err := d.SelectVersions(test.specs...)
+		if err != nil {
+			t.Errorf("%v: %v", test.specs, err)
+			continue
+		}
+		var got string
+		for _, version := range versions {
+			got += version.Hash
+		}
+		if got != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.specs, test.expected, got)
+		}
+	}
+
+	if _, err := d.SelectVersions("b..f"); err == nil {
+		t.Errorf("expected an unknown revision to be rejected")
+	}
+}
+
+func TestBundle(t *testing.T) {
+	source, ctx := setup(t)
+
+	dir := t.TempDir()
+	var hashes []string
+	for _, content := range []string{"one", "two"} {
+		if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := source.Add(ctx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	manifest, err := source.SaveManifest(ctx, &Manifest{Versions: []*Version{
+		{Date: time.Now(), Hash: hashes[0], PathType: PathTypeDirectory},
+		{Date: time.Now().Add(time.Minute), Hash: hashes[1], PathType: PathTypeDirectory, Parents: []string{hashes[0]}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := &Dorothy{Context: ctx, Ipfs: *source, Manifest: manifest}
+
+	var bundle bytes.Buffer
+	created, err := sender.CreateBundle(&bundle, hashes[0]+"..")
+	if err != nil {
+		t.Fatal(err)
+	} else if created.Manifest != manifest.Hash || len(created.Versions) != 1 || created.Versions[0].Hash != hashes[1] {
+		t.Fatalf("expected to bundle only the second version, got %+v", created)
+	}
+
+	client, ctx := setup(t)
+	empty, err := client.CreateEmptyManifest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: empty}
+	if err := receiver.WriteManifestFile(ReflogInit, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, conflicts, err := receiver.FetchBundle(&bundle, "test.bundle")
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("unexpected result %v, %v", conflicts, err)
+	}
+	if len(fetched.Versions) != 1 || !fetched.Versions[0].IsComplete() {
+		t.Errorf("expected the second version to be complete, got %+v", fetched.Versions)
+	}
+	if len(receiver.Manifest.Versions) != 2 {
+		t.Errorf("expected the whole history to be merged, got %d version(s)", len(receiver.Manifest.Versions))
+	}
+
+	pinned, err := client.pinnedSet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if pinned[hashes[0]] || !pinned[hashes[1]] {
+		t.Errorf("expected only the bundled version to be pinned, got %v", pinned)
+	}
+
+	if _, _, err := receiver.FetchBundle(bytes.NewReader(nil), "empty.bundle"); err == nil {
+		t.Errorf("expected an empty file to be rejected")
+	}
+}
@@ -0,0 +1,633 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/boxo/files"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/core/coreiface/options"
+	"golang.org/x/sync/errgroup"
+
+	ipath "github.com/ipfs/boxo/path"
+	icore "github.com/ipfs/kubo/core/coreiface"
+)
+
+const (
+	DefaultCheckoutWorkers = 4
+	partialSuffix          = ".dorothy-part"
+)
+
+type CheckoutProgress struct {
+	Files      int
+	TotalFiles int
+	Bytes      uint64
+	TotalBytes uint64
+}
+
+var ErrLocalChanges = errors.New("checkout would discard local changes")
+
+type CheckoutSettings struct {
+	Include   []string
+	Exclude   []string
+	Workers   int
+	Track     bool
+	Base      string
+	Force     bool
+	Progress  func(CheckoutProgress)
+	Untracked func(string)
+	// TargetAdd and BaseAdd are the settings with which the version being
+	// checked out and the base version were added, if they were recorded.
+	TargetAdd *AddSettings
+	BaseAdd   *AddSettings
+}
+
+type CheckoutOption func(*CheckoutSettings) *CheckoutSettings
+
+func CheckoutInclude(patterns ...string) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.Include = append(cfg.Include, patterns...)
+		return cfg
+	}
+}
+
+func CheckoutExclude(patterns ...string) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.Exclude = append(cfg.Exclude, patterns...)
+		return cfg
+	}
+}
+
+func CheckoutWorkers(workers int) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		if workers > 0 {
+			cfg.Workers = workers
+		}
+		return cfg
+	}
+}
+
+// CheckoutTrack treats the destination as a working tree. Files at the
+// destination are compared with the version being checked out and with the
+// base version, so that files removed since the base are deleted and local
+// changes are not silently overwritten. Without it, checkout only writes the
+// files of the version over whatever is already there.
+func CheckoutTrack(track bool) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.Track = track
+		return cfg
+	}
+}
+
+// CheckoutBase tells checkout which version the destination was last checked
+// out from, so that unmodified files can be replaced or removed safely.
+func CheckoutBase(hash string) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.Base = hash
+		return cfg
+	}
+}
+
+func CheckoutForce(force bool) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.Force = force
+		return cfg
+	}
+}
+
+func CheckoutWithProgress(progress func(CheckoutProgress)) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.Progress = progress
+		return cfg
+	}
+}
+
+// CheckoutAddSettings gives the settings with which the version being checked
+// out and the base version were added, so that local files are hashed the
+// same way when they are compared with them.
+func CheckoutAddSettings(target, base *AddSettings) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.TargetAdd = target
+		cfg.BaseAdd = base
+		return cfg
+	}
+}
+
+// CheckoutWithUntracked is called with the path of each file in a tracked
+// destination which is in neither the base version nor the version being
+// checked out. Such files are always left in place.
+func CheckoutWithUntracked(report func(string)) CheckoutOption {
+	return func(cfg *CheckoutSettings) *CheckoutSettings {
+		cfg.Untracked = report
+		return cfg
+	}
+}
+
+func NewCheckoutSettings(options ...CheckoutOption) *CheckoutSettings {
+	cfg := &CheckoutSettings{
+		Workers: DefaultCheckoutWorkers,
+	}
+	for _, option := range options {
+		cfg = option(cfg)
+	}
+	return cfg
+}
+
+func (cfg *CheckoutSettings) IsSparse() bool {
+	return len(cfg.Include) != 0 || len(cfg.Exclude) != 0
+}
+
+func (cfg *CheckoutSettings) Excludes(p string) bool {
+	for _, pattern := range cfg.Exclude {
+		if MatchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *CheckoutSettings) Includes(p string) bool {
+	if cfg.Excludes(p) {
+		return false
+	}
+	if len(cfg.Include) == 0 {
+		return true
+	}
+	for _, pattern := range cfg.Include {
+		if MatchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob reports whether a slash-separated path, or any of its parent
+// directories, matches the pattern. Patterns use path.Match syntax with the
+// addition of "**", which matches any number of path segments. As with
+// .gitignore, a pattern without a slash matches at any depth.
+func MatchGlob(pattern, p string) bool {
+	pattern = strings.Trim(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	patterns := strings.Split(pattern, "/")
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	return matchSegments(patterns, segments)
+}
+
+func matchSegments(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(patterns[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(patterns[0], segments[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(patterns[1:], segments[1:])
+}
+
+type checkoutJob struct {
+	entry   TreeEntry
+	dest    string
+	add     *AddSettings
+	current bool
+}
+
+type checkoutTracker struct {
+	sync.Mutex
+	progress CheckoutProgress
+	report   func(CheckoutProgress)
+}
+
+func (t *checkoutTracker) add(bytes int64, files int) {
+	if t.report == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.progress.Bytes = uint64(int64(t.progress.Bytes) + bytes)
+	t.progress.Files += files
+	t.report(t.progress)
+}
+
+type progressWriter struct {
+	io.Writer
+	ctx     context.Context
+	tracker *checkoutTracker
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := w.Writer.Write(p)
+	w.tracker.add(int64(n), 0)
+	return n, err
+}
+
+func (s *Ipfs) Checkout(ctx context.Context, hash, subpath, dest string, cfg *CheckoutSettings) error {
+	root, err := s.Stat(ctx, hash, subpath)
+	if err != nil {
+		return err
+	}
+
+	var jobs []checkoutJob
+	var dirs []string
+	if root.IsDir() {
+		dirs = append(dirs, dest)
+		err = s.walk(ctx, ipath.FromCid(cid.MustParse(root.Cid)), "", true, func(entry TreeEntry) error {
+			filename := filepath.Join(dest, filepath.FromSlash(entry.Path))
+			if entry.IsDir() {
+				if cfg.Excludes(entry.Path) {
+					return fs.SkipDir
+				} else if cfg.Includes(entry.Path) {
+					dirs = append(dirs, filename)
+				}
+				return nil
+			}
+
+			if cfg.Includes(entry.Path) {
+				jobs = append(jobs, checkoutJob{entry: entry, dest: filename, add: cfg.TargetAdd})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	} else if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		return fmt.Errorf("cannot checkout a file over directory %q", dest)
+	} else {
+		jobs = append(jobs, checkoutJob{entry: root, dest: dest, add: cfg.TargetAdd})
+	}
+
+	if cfg.Track {
+		if err := s.prepareDestination(ctx, subpath, dest, jobs, cfg); err != nil {
+			return err
+		}
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	tracker := &checkoutTracker{report: cfg.Progress}
+	for _, job := range jobs {
+		tracker.progress.TotalFiles++
+		tracker.progress.TotalBytes += job.entry.Size
+	}
+	tracker.add(0, 0)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(cfg.Workers, 1))
+	for _, job := range jobs {
+		if gctx.Err() != nil {
+			break
+		}
+		job := job
+		g.Go(func() error {
+			return s.checkoutFile(gctx, job, tracker)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// prepareDestination compares the files already at a tracked dest with the
+// version being checked out. Files which already match are marked as current,
+// and files of the base version which are not part of the new one are
+// removed. Files which are in neither version are untracked: they are
+// reported and left alone. Unless cfg.Force is set, it refuses to overwrite
+// or remove any file which differs from the base version, since that would
+// lose uncommitted changes.
+func (s *Ipfs) prepareDestination(ctx context.Context, subpath, dest string, jobs []checkoutJob, cfg *CheckoutSettings) error {
+	local, err := localFiles(dest, cfg)
+	if err != nil || len(local) == 0 {
+		return err
+	}
+
+	base := make(map[string]TreeEntry)
+	if cfg.Base != "" {
+		if root, err := s.Stat(ctx, cfg.Base, subpath); err == nil && root.IsDir() {
+			err := s.walk(ctx, ipath.FromCid(cid.MustParse(root.Cid)), "", true, func(entry TreeEntry) error {
+				if !entry.IsDir() {
+					base[entry.Path] = entry
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		} else if err == nil {
+			base["."] = root
+		}
+	}
+
+	target := make(map[string]*checkoutJob)
+	for i := range jobs {
+		rel, err := filepath.Rel(dest, jobs[i].dest)
+		if err != nil {
+			return err
+		}
+		target[filepath.ToSlash(rel)] = &jobs[i]
+	}
+
+	var conflicts, remove, untracked []string
+	for rel, info := range local {
+		filename := filepath.Join(dest, filepath.FromSlash(rel))
+		entry, tracked := base[rel]
+		if job, ok := target[rel]; ok {
+			if job.current, err = s.matchesEntry(ctx, filename, info, job.entry, job.add); err != nil {
+				return err
+			} else if job.current {
+				continue
+			}
+		} else if tracked {
+			remove = append(remove, filename)
+		} else {
+			untracked = append(untracked, rel)
+			continue
+		}
+
+		if cfg.Force {
+			continue
+		}
+		if !tracked {
+			conflicts = append(conflicts, rel)
+		} else if clean, err := s.matchesEntry(ctx, filename, info, entry, cfg.BaseAdd); err != nil {
+			return err
+		} else if !clean {
+			conflicts = append(conflicts, rel)
+		}
+	}
+
+	if len(conflicts) != 0 {
+		slices.Sort(conflicts)
+		return fmt.Errorf("%w:\n  %s", ErrLocalChanges, strings.Join(conflicts, "\n  "))
+	}
+
+	if cfg.Untracked != nil {
+		slices.Sort(untracked)
+		for _, rel := range untracked {
+			cfg.Untracked(rel)
+		}
+	}
+
+	for _, filename := range remove {
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		for dir := filepath.Dir(filename); dir != dest && strings.HasPrefix(dir, dest); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// localFiles lists the files at dest that a checkout with cfg would manage,
+// keyed by their slash-separated path relative to dest. The repository's own
+// .dorothy directory and partially downloaded files are ignored.
+func localFiles(dest string, cfg *CheckoutSettings) (map[string]fs.FileInfo, error) {
+	info, err := os.Lstat(dest)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return map[string]fs.FileInfo{".": info}, nil
+	}
+
+	local := make(map[string]fs.FileInfo)
+	err = filepath.WalkDir(dest, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if p == dest {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dest, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel == ".dorothy" || cfg.Excludes(rel) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(rel, partialSuffix) || !cfg.Includes(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		local[rel] = info
+		return nil
+	})
+	return local, err
+}
+
+func (s *Ipfs) matchesEntry(ctx context.Context, filename string, info fs.FileInfo, entry TreeEntry, add *AddSettings) (bool, error) {
+	switch {
+	case entry.IsDir():
+		return false, nil
+	case info.Mode().IsRegular():
+		if entry.Type != icore.TFile.String() || uint64(info.Size()) != entry.Size {
+			return false, nil
+		}
+	case info.Mode()&fs.ModeSymlink != 0:
+		if entry.Type != icore.TSymlink.String() {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	return s.matchesCid(ctx, filename, entry.Cid, add)
+}
+
+func (s *Ipfs) checkoutFile(ctx context.Context, job checkoutJob, tracker *checkoutTracker) error {
+	if err := os.MkdirAll(filepath.Dir(job.dest), 0755); err != nil {
+		return err
+	}
+
+	if job.current {
+		tracker.add(int64(job.entry.Size), 1)
+		return nil
+	}
+
+	if job.entry.Type != icore.TFile.String() {
+		if err := os.RemoveAll(job.dest); err != nil {
+			return err
+		}
+		if err := s.getNode(ctx, job.entry.Cid, job.dest); err != nil {
+			return err
+		}
+		tracker.add(int64(job.entry.Size), 1)
+		return nil
+	}
+
+	partial := job.dest + partialSuffix
+	var offset int64
+	if info, err := os.Stat(partial); err == nil && info.Mode().IsRegular() && uint64(info.Size()) <= job.entry.Size {
+		offset = info.Size()
+	}
+
+	if err := s.download(ctx, job.entry.Cid, partial, offset, tracker); err != nil {
+		return err
+	}
+
+	if offset != 0 {
+		if ok, err := s.matchesCid(ctx, partial, job.entry.Cid, job.add); err != nil {
+			return err
+		} else if !ok {
+			tracker.add(-int64(job.entry.Size), 0)
+			if err := s.download(ctx, job.entry.Cid, partial, 0, tracker); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.Rename(partial, job.dest); err != nil {
+		return err
+	}
+	tracker.add(0, 1)
+
+	return nil
+}
+
+func (s *Ipfs) download(ctx context.Context, c, dest string, offset int64, tracker *checkoutTracker) error {
+	id, err := cid.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	node, err := s.Unixfs().Get(ctx, ipath.FromCid(id))
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	file, ok := node.(files.File)
+	if !ok {
+		return fmt.Errorf("%s is not a file", c)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	} else {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		flags |= os.O_APPEND
+	}
+
+	handle, err := os.OpenFile(dest, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	tracker.add(offset, 0)
+	if _, err := io.Copy(progressWriter{handle, ctx, tracker}, file); err != nil {
+		return err
+	}
+
+	return handle.Sync()
+}
+
+// matchesCid reports whether the local file hashes to c when added with the
+// settings the version was committed with, or with settings inferred from c
+// if add is nil.
+func (s *Ipfs) matchesCid(ctx context.Context, filename, c string, add *AddSettings) (bool, error) {
+	expected, err := cid.Parse(c)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Lstat(filename)
+	if err != nil {
+		return false, err
+	}
+
+	var node files.Node
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(filename)
+		if err != nil {
+			return false, err
+		}
+		node = files.NewLinkFile(target, info)
+	} else {
+		handle, err := os.Open(filename)
+		if err != nil {
+			return false, err
+		}
+		defer handle.Close()
+		node = files.NewReaderFile(handle)
+	}
+
+	if add == nil {
+		if add, err = s.inferAddSettings(ctx, expected); err != nil {
+			return false, err
+		}
+	}
+	addOptions, err := add.options()
+	if err != nil {
+		return false, err
+	}
+
+	computed, err := s.Unixfs().Add(
+		ctx,
+		node,
+		append(addOptions, options.Unixfs.HashOnly(true), options.Unixfs.Pin(false))...,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return computed.RootCid().Equals(expected), nil
+}
+
+func (s *Ipfs) getNode(ctx context.Context, c, dest string) error {
+	id, err := cid.Parse(c)
+	if err != nil {
+		return err
+	}
+
+	node, err := s.Unixfs().Get(ctx, ipath.FromCid(id))
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	return files.WriteTo(node, dest)
+}
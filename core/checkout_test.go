@@ -0,0 +1,364 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"site-a", "site-a", true},
+		{"site-a", "site-a/data.csv", true},
+		{"site-a", "site-b/data.csv", false},
+		{"*.csv", "data.csv", true},
+		{"*.csv", "site-a/data.csv", true},
+		{"*.csv", "site-a/data.txt", false},
+		{"site-a/*.csv", "site-a/data.csv", true},
+		{"site-a/*.csv", "site-b/site-a/data.csv", false},
+		{"site-*/raw", "site-b/raw/0001.bin", true},
+		{"**/raw", "2023/site-b/raw/0001.bin", true},
+		{"site-a/**/*.csv", "site-a/2023/01/data.csv", true},
+		{"site-a/**/*.csv", "site-a/data.csv", true},
+		{"site-a/**/*.csv", "site-b/2023/data.csv", false},
+		{"[", "[", false},
+	}
+
+	for _, c := range cases {
+		if got := MatchGlob(c.pattern, c.path); got != c.match {
+			t.Errorf("MatchGlob(%q, %q): expected %v, got %v", c.pattern, c.path, c.match, got)
+		}
+	}
+}
+
+func TestCheckoutSettingsIncludes(t *testing.T) {
+	cfg := NewCheckoutSettings(CheckoutInclude("site-a", "*.md"), CheckoutExclude("*.tmp"))
+
+	cases := map[string]bool{
+		"README.md":        true,
+		"site-a/data.csv":  true,
+		"site-a/data.tmp":  false,
+		"site-b/data.csv":  false,
+		"site-b/README.md": true,
+	}
+	for p, expected := range cases {
+		if got := cfg.Includes(p); got != expected {
+			t.Errorf("Includes(%q): expected %v, got %v", p, expected, got)
+		}
+	}
+
+	if NewCheckoutSettings().IsSparse() {
+		t.Errorf("expected an empty config not to be sparse")
+	}
+}
+
+func listFiles(t *testing.T, root string) []string {
+	t.Helper()
+
+	var found []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			found = append(found, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(found)
+	return found
+}
+
+func TestCheckout(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"README.md":          "# Data\n",
+		"site-a/data.csv":    "1\n",
+		"site-a/scratch.tmp": "x\n",
+		"site-b/data.csv":    "2\n",
+	})
+
+	cases := []struct {
+		subpath  string
+		options  []CheckoutOption
+		expected []string
+	}{
+		{
+			subpath:  "site-a",
+			expected: []string{"data.csv", "scratch.tmp"},
+		},
+		{
+			options:  []CheckoutOption{CheckoutInclude("site-b")},
+			expected: []string{"site-b/data.csv"},
+		},
+		{
+			options:  []CheckoutOption{CheckoutExclude("site-b", "*.tmp")},
+			expected: []string{"README.md", "site-a/data.csv"},
+		},
+		{
+			subpath:  "site-a",
+			options:  []CheckoutOption{CheckoutInclude("*.csv")},
+			expected: []string{"data.csv"},
+		},
+	}
+
+	for _, c := range cases {
+		dest := filepath.Join(t.TempDir(), "checkout")
+		if err := client.Checkout(ctx, hash, c.subpath, dest, NewCheckoutSettings(c.options...)); err != nil {
+			t.Fatal(err)
+		}
+		if got := listFiles(t, dest); !slices.Equal(got, c.expected) {
+			t.Errorf("expected %q, got %q", c.expected, got)
+		}
+	}
+}
+
+func TestCheckoutSingleFile(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"site-a/data.csv": "1\n",
+	})
+
+	dest := filepath.Join(t.TempDir(), "data.csv")
+	if err := client.Checkout(ctx, hash, "site-a/data.csv", dest, NewCheckoutSettings()); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "1\n" {
+		t.Errorf("expected %q, got %q", "1\n", content)
+	}
+}
+
+func TestCheckoutResume(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"complete.csv": "1,2,3\n",
+		"partial.csv":  "4,5,6\n",
+		"stale.csv":    "7,8,9\n",
+	})
+
+	dest := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dest, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("complete.csv", "1,2,3\n")
+	write("partial.csv"+partialSuffix, "4,5")
+	write("stale.csv", "0,0,0\n")
+
+	var last CheckoutProgress
+	settings := NewCheckoutSettings(CheckoutWorkers(2), CheckoutForce(true), CheckoutWithProgress(func(p CheckoutProgress) {
+		last = p
+	}))
+	if err := client.Checkout(ctx, hash, "", dest, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"complete.csv", "partial.csv", "stale.csv"}
+	if got := listFiles(t, dest); !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	for name, content := range map[string]string{"partial.csv": "4,5,6\n", "stale.csv": "7,8,9\n"} {
+		got, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, got)
+		}
+	}
+
+	if last.Files != 3 || last.TotalFiles != 3 {
+		t.Errorf("expected 3 of 3 files, got %d of %d", last.Files, last.TotalFiles)
+	}
+	if last.Bytes != 18 || last.TotalBytes != 18 {
+		t.Errorf("expected 18 of 18 bytes, got %d of %d", last.Bytes, last.TotalBytes)
+	}
+}
+
+func TestCheckoutLocalChanges(t *testing.T) {
+	client, ctx := setup(t)
+
+	base := addTree(t, client, ctx, map[string]string{
+		"kept.csv":     "1\n",
+		"changed.csv":  "2\n",
+		"old/gone.csv": "3\n",
+	})
+	target := addTree(t, client, ctx, map[string]string{
+		"kept.csv":    "1\n",
+		"changed.csv": "4\n",
+		"new.csv":     "5\n",
+	})
+
+	dest := t.TempDir()
+	if err := client.Checkout(ctx, base, "", dest, NewCheckoutSettings()); err != nil {
+		t.Fatal(err)
+	}
+
+	track := func(options ...CheckoutOption) *CheckoutSettings {
+		return NewCheckoutSettings(append([]CheckoutOption{CheckoutTrack(true)}, options...)...)
+	}
+
+	err := client.Checkout(ctx, target, "", dest, track())
+	if !errors.Is(err, ErrLocalChanges) {
+		t.Fatalf("expected ErrLocalChanges without a base, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dest, "scratch.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "changed.csv"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = client.Checkout(ctx, target, "", dest, track(CheckoutBase(base)))
+	if !errors.Is(err, ErrLocalChanges) || !strings.Contains(err.Error(), "changed.csv") || strings.Contains(err.Error(), "scratch.txt") {
+		t.Fatalf("expected only changed.csv to conflict, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dest, "changed.csv"), []byte("2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var untracked []string
+	err = client.Checkout(ctx, target, "", dest, track(CheckoutBase(base), CheckoutWithUntracked(func(path string) {
+		untracked = append(untracked, path)
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(untracked, []string{"scratch.txt"}) {
+		t.Errorf("expected scratch.txt to be reported as untracked, got %q", untracked)
+	}
+
+	expected := []string{"changed.csv", "kept.csv", "new.csv", "scratch.txt"}
+	if got := listFiles(t, dest); !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "old")); !os.IsNotExist(err) {
+		t.Errorf("expected the emptied directory to be removed")
+	}
+
+	if err := os.WriteFile(filepath.Join(dest, "changed.csv"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = client.Checkout(ctx, base, "", dest, track(CheckoutBase(target)))
+	if !errors.Is(err, ErrLocalChanges) || !strings.Contains(err.Error(), "changed.csv") {
+		t.Fatalf("expected changed.csv to conflict, got %v", err)
+	}
+
+	if err := client.Checkout(ctx, base, "", dest, track(CheckoutBase(target), CheckoutForce(true))); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"changed.csv", "kept.csv", "old/gone.csv", "scratch.txt"}
+	if got := listFiles(t, dest); !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	content, err := os.ReadFile(filepath.Join(dest, "changed.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "2\n" {
+		t.Errorf("expected %q, got %q", "2\n", content)
+	}
+}
+
+func TestCheckoutUntracked(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"data.csv": "1\n",
+	})
+
+	dest := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dest, "data.csv"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Checkout(ctx, hash, "", dest, NewCheckoutSettings()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"data.csv", "notes.txt"}
+	if got := listFiles(t, dest); !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	content, err := os.ReadFile(filepath.Join(dest, "data.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "1\n" {
+		t.Errorf("expected %q, got %q", "1\n", content)
+	}
+}
+
+func TestCheckoutResumeMismatch(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"data.csv": "1,2,3\n",
+	})
+
+	dest := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dest, "data.csv"+partialSuffix), []byte("9,9"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Checkout(ctx, hash, "", dest, NewCheckoutSettings()); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dest, "data.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "1,2,3\n" {
+		t.Errorf("expected %q, got %q", "1,2,3\n", got)
+	}
+}
+
+func TestCheckoutCanceled(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"data.csv": "1,2,3\n",
+	})
+
+	canceled, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	dest := t.TempDir()
+	settings := NewCheckoutSettings(CheckoutWithProgress(func(CheckoutProgress) {
+		cancel()
+	}))
+	if err := client.Checkout(canceled, hash, "", dest, settings); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "data.csv")); !os.IsNotExist(err) {
+		t.Errorf("expected data.csv not to be checked out")
+	}
+}
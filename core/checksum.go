@@ -0,0 +1,362 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/boxo/files"
+	"github.com/ipfs/boxo/path"
+	"github.com/ipfs/kubo/core/coreiface/options"
+)
+
+// FileChecksum holds the checksums of one file of a version, at the path the
+// file has within it.
+type FileChecksum struct {
+	Path   string `json:"path"`
+	Sha256 string `json:"sha256"`
+	Md5    string `json:"md5,omitempty"`
+}
+
+// Checksums is the sidecar object which records the checksum of every file
+// of a version, so that the files can be verified without IPFS.
+type Checksums struct {
+	Version string         `json:"version"`
+	Files   []FileChecksum `json:"files"`
+}
+
+// HasMd5 reports whether md5 checksums were recorded.
+func (c *Checksums) HasMd5() bool {
+	return len(c.Files) != 0 && c.Files[0].Md5 != ""
+}
+
+// ComputeChecksums reads every file of a version and computes its sha256
+// and, if withMd5, its md5 checksum.
+func (s *Ipfs) ComputeChecksums(ctx context.Context, version string, withMd5 bool) (*Checksums, error) {
+	p, err := versionPath(version, "")
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := s.Unixfs().Get(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Close()
+
+	checksums := &Checksums{Version: version, Files: []FileChecksum{}}
+	err = files.Walk(node, func(fpath string, node files.Node) error {
+		if _, ok := node.(*files.Symlink); ok {
+			return nil
+		}
+		file, ok := node.(files.File)
+		if !ok {
+			return nil
+		}
+		if fpath == "" {
+			fpath = version
+		}
+
+		sha := sha256.New()
+		writers := []io.Writer{sha}
+		var sum hash.Hash
+		if withMd5 {
+			sum = md5.New()
+			writers = append(writers, sum)
+		}
+		if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+			return fmt.Errorf("%q: %v", fpath, err)
+		}
+
+		checksum := FileChecksum{Path: fpath, Sha256: hex.EncodeToString(sha.Sum(nil))}
+		if withMd5 {
+			checksum.Md5 = hex.EncodeToString(sum.Sum(nil))
+		}
+		checksums.Files = append(checksums.Files, checksum)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(checksums.Files, func(i, j int) bool {
+		return checksums.Files[i].Path < checksums.Files[j].Path
+	})
+	return checksums, nil
+}
+
+// SaveChecksums adds the checksums of a version to IPFS and returns the hash
+// of the sidecar object.
+func (s *Ipfs) SaveChecksums(ctx context.Context, checksums *Checksums, pin bool) (string, error) {
+	buffer := new(bytes.Buffer)
+	if err := json.NewEncoder(buffer).Encode(checksums); err != nil {
+		return "", err
+	}
+
+	p, err := s.Unixfs().Add(ctx, files.NewReaderFile(buffer), options.Unixfs.Pin(pin))
+	if err != nil {
+		return "", err
+	}
+	return p.RootCid().String(), nil
+}
+
+func (s *Ipfs) GetChecksums(ctx context.Context, hash string) (*Checksums, error) {
+	p, err := path.NewPath("/ipfs/" + hash)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := s.Unixfs().Get(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Close()
+
+	file := files.ToFile(node)
+	if file == nil {
+		return nil, fmt.Errorf("checksums %s are not a file", hash)
+	}
+
+	var checksums Checksums
+	if err := json.NewDecoder(file).Decode(&checksums); err != nil {
+		return nil, fmt.Errorf("cannot parse checksums %s: %v", hash, err)
+	}
+	return &checksums, nil
+}
+
+// checksummer computes the checksums of files as they are read, so that they
+// can be recorded while a version is added rather than by reading it back.
+type checksummer struct {
+	withMd5 bool
+
+	mu       sync.Mutex
+	files    []FileChecksum
+	pending  int
+	bytes    uint64
+	dirs     int
+	symlinks int
+}
+
+func (d *Dorothy) newChecksummer() *checksummer {
+	return &checksummer{withMd5: d.Config.Checksums != nil && d.Config.Checksums.Md5}
+}
+
+// wrap returns node with every file beneath it checksummed as it is read.
+// The files are given paths relative to fpath.
+func (c *checksummer) wrap(fpath string, node files.Node) files.Node {
+	switch n := node.(type) {
+	case *files.Symlink:
+		c.mu.Lock()
+		c.symlinks++
+		c.mu.Unlock()
+		return n
+	case files.Directory:
+		// The root is not an entry of the version.
+		if fpath != "" {
+			c.mu.Lock()
+			c.dirs++
+			c.mu.Unlock()
+		}
+		return &checksumDirectory{Directory: n, path: fpath, checksummer: c}
+	case files.File:
+		c.mu.Lock()
+		c.pending++
+		c.mu.Unlock()
+
+		file := &checksumFile{File: n, path: fpath, checksummer: c, sha: sha256.New()}
+		writers := []io.Writer{file.sha}
+		if c.withMd5 {
+			file.md5 = md5.New()
+			writers = append(writers, file.md5)
+		}
+		file.writer = io.MultiWriter(writers...)
+
+		// Adding with --nocopy needs to know where the file is.
+		if info, ok := n.(files.FileInfo); ok {
+			return &checksumFileInfo{checksumFile: file, info: info}
+		}
+		return file
+	}
+	return node
+}
+
+func (c *checksummer) record(checksum FileChecksum, size uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = append(c.files, checksum)
+	c.bytes += size
+}
+
+// checksums returns the checksums of the version, or false if any of its
+// files were not read through to the end.
+func (c *checksummer) checksums(version string) (*Checksums, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.files) != c.pending {
+		return nil, false
+	}
+
+	checksums := &Checksums{Version: version, Files: []FileChecksum{}}
+	for _, checksum := range c.files {
+		if checksum.Path == "" {
+			checksum.Path = version
+		}
+		checksums.Files = append(checksums.Files, checksum)
+	}
+	sort.Slice(checksums.Files, func(i, j int) bool {
+		return checksums.Files[i].Path < checksums.Files[j].Path
+	})
+	return checksums, true
+}
+
+// summary returns the summary of the version, or false if any of its files
+// were not read through to the end. Symlinks are left to Summarize, since
+// their size is not what was read.
+func (c *checksummer) summary() (*TreeSummary, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.files) != c.pending || c.symlinks != 0 {
+		return nil, false
+	}
+	return &TreeSummary{Files: len(c.files), Directories: c.dirs, Bytes: c.bytes}, true
+}
+
+type checksumDirectory struct {
+	files.Directory
+	path        string
+	checksummer *checksummer
+}
+
+func (d *checksumDirectory) Entries() files.DirIterator {
+	return &checksumIterator{DirIterator: d.Directory.Entries(), dir: d}
+}
+
+type checksumIterator struct {
+	files.DirIterator
+	dir *checksumDirectory
+}
+
+func (it *checksumIterator) Node() files.Node {
+	return it.dir.checksummer.wrap(filepath.Join(it.dir.path, it.Name()), it.DirIterator.Node())
+}
+
+type checksumFile struct {
+	files.File
+	path        string
+	checksummer *checksummer
+	sha, md5    hash.Hash
+	writer      io.Writer
+	size        uint64
+	done        bool
+	seeked      bool
+}
+
+func (f *checksumFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.writer.Write(p[:n])
+	f.size += uint64(n)
+	if err == io.EOF && !f.done && !f.seeked {
+		f.done = true
+		checksum := FileChecksum{Path: f.path, Sha256: hex.EncodeToString(f.sha.Sum(nil))}
+		if f.md5 != nil {
+			checksum.Md5 = hex.EncodeToString(f.md5.Sum(nil))
+		}
+		f.checksummer.record(checksum, f.size)
+	}
+	return n, err
+}
+
+// Seek is passed through, but the file is then left unrecorded since what
+// was read is no longer its content in order.
+func (f *checksumFile) Seek(offset int64, whence int) (int64, error) {
+	f.seeked = true
+	return f.File.Seek(offset, whence)
+}
+
+type checksumFileInfo struct {
+	*checksumFile
+	info files.FileInfo
+}
+
+func (f *checksumFileInfo) AbsPath() string {
+	return f.info.AbsPath()
+}
+
+func (f *checksumFileInfo) Stat() os.FileInfo {
+	return f.info.Stat()
+}
+
+// recordChecksums saves the checksums of a version as its sidecar object,
+// returning its hash. The checksums computed while adding the version are
+// used if they are complete, and otherwise the version is read to compute
+// them.
+func (d *Dorothy) recordChecksums(hash string, computed *checksummer, pin bool) (string, error) {
+	if computed != nil {
+		if checksums, ok := computed.checksums(hash); ok {
+			return d.Ipfs.SaveChecksums(d, checksums, pin)
+		}
+	}
+
+	withMd5 := d.Config.Checksums != nil && d.Config.Checksums.Md5
+	checksums, err := d.Ipfs.ComputeChecksums(d, hash, withMd5)
+	if err != nil {
+		return "", fmt.Errorf("cannot compute checksums of %s: %v", hash, err)
+	}
+	return d.Ipfs.SaveChecksums(d, checksums, pin)
+}
+
+// recordSummary returns the summary of a version, taken from what was read
+// while adding it if possible and otherwise by walking it.
+func (d *Dorothy) recordSummary(hash string, computed *checksummer) (*TreeSummary, error) {
+	if computed != nil {
+		if summary, ok := computed.summary(); ok {
+			return summary, nil
+		}
+	}
+
+	summary, err := d.Ipfs.Summarize(d, hash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot summarize %s: %v", hash, err)
+	}
+	return &summary, nil
+}
+
+// Checksums returns the checksums of a version, computing them if they were
+// not recorded when it was committed. The bool reports whether they were
+// recorded.
+func (d *Dorothy) Checksums(rev string, withMd5 bool) (*Checksums, bool, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, false, fmt.Errorf("not connected to IPFS")
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if version.Checksums != "" {
+		checksums, err := d.Ipfs.GetChecksums(d, version.Checksums)
+		if err != nil {
+			return nil, false, err
+		} else if checksums.Version != version.Hash {
+			return nil, false, fmt.Errorf("checksums %s are of version %s, not %s", version.Checksums, checksums.Version, version.Hash)
+		} else if !withMd5 || checksums.HasMd5() || len(checksums.Files) == 0 {
+			return checksums, true, nil
+		}
+	}
+
+	checksums, err := d.Ipfs.ComputeChecksums(d, version.Hash, withMd5)
+	return checksums, false, err
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/boxo/files"
+)
+
+func TestComputeChecksums(t *testing.T) {
+	client, d, hash := exportTestVersion(t)
+
+	checksums, err := client.ComputeChecksums(d, hash, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []FileChecksum{
+		{
+			Path:   "a.txt",
+			Sha256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb",
+			Md5:    "0cc175b9c0f1b6a831c399e269772661",
+		},
+		{
+			Path:   "sub/b.txt",
+			Sha256: "3b64db95cb55c763391c707108489ae18b4112d783300de38e033b4c98c3deaf",
+			Md5:    "21ad0bd836b90d08f4cf640b4c298e7c",
+		},
+	}
+	if checksums.Version != hash || len(checksums.Files) != len(expected) {
+		t.Fatalf("unexpected checksums %+v", checksums)
+	}
+	for i, file := range checksums.Files {
+		if file != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], file)
+		}
+	}
+
+	if checksums, err := client.ComputeChecksums(d, hash, false); err != nil {
+		t.Fatal(err)
+	} else if checksums.HasMd5() {
+		t.Errorf("expected no md5 checksums")
+	}
+}
+
+func TestRecordedChecksums(t *testing.T) {
+	client, d, hash := exportTestVersion(t)
+
+	sidecar, err := d.recordChecksums(hash, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Manifest.Versions[0].Checksums = sidecar
+
+	saved, err := client.GetChecksums(d, sidecar)
+	if err != nil {
+		t.Fatal(err)
+	} else if saved.Version != hash || len(saved.Files) != 2 || saved.HasMd5() {
+		t.Fatalf("unexpected checksums %+v", saved)
+	}
+
+	checksums, recorded, err := d.Checksums(hash, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if !recorded || len(checksums.Files) != 2 {
+		t.Errorf("expected the recorded checksums, got %+v", checksums)
+	}
+
+	// md5 checksums were not recorded, so they must be computed.
+	checksums, recorded, err = d.Checksums(hash, true)
+	if err != nil {
+		t.Fatal(err)
+	} else if recorded || !checksums.HasMd5() {
+		t.Errorf("expected computed md5 checksums, got %+v", checksums)
+	}
+}
+
+func TestChecksumsWhileAdding(t *testing.T) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	for _, sub := range []string{"sub", "empty"} {
+		if err := os.MkdirAll(filepath.Join(dir, "data", sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range map[string]string{"data/a.txt": "a", "data/sub/b.txt": "bb", "c.txt": "ccc"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := map[string][]string{
+		"directory": {filepath.Join(dir, "data")},
+		"file":      {filepath.Join(dir, "c.txt")},
+		"many":      {filepath.Join(dir, "data"), filepath.Join(dir, "c.txt")},
+	}
+	for name, paths := range cases {
+		var node files.Node
+		var err error
+		if len(paths) == 1 {
+			node, err = getUnixFileNode(paths[0])
+		} else {
+			node, err = getUnixFileNodes(paths)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		summer := &checksummer{withMd5: true}
+		hash, err := client.AddNode(ctx, summer.wrap("", node))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		computed, ok := summer.checksums(hash)
+		if !ok {
+			t.Errorf("%s: expected every file to be checksummed while adding", name)
+			continue
+		}
+		expected, err := client.ComputeChecksums(ctx, hash, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(computed.Files) != len(expected.Files) {
+			t.Errorf("%s: expected %+v, got %+v", name, expected.Files, computed.Files)
+			continue
+		}
+		for i := range computed.Files {
+			if computed.Files[i] != expected.Files[i] {
+				t.Errorf("%s: expected %+v, got %+v", name, expected.Files[i], computed.Files[i])
+			}
+		}
+
+		summary, ok := summer.summary()
+		if !ok {
+			t.Errorf("%s: expected the version to be summarized while adding", name)
+		} else if walked, err := client.Summarize(ctx, hash); err != nil {
+			t.Fatal(err)
+		} else if *summary != walked {
+			t.Errorf("%s: expected summary %v, got %v", name, walked, *summary)
+		}
+	}
+
+	// The size of a symlink is not what is read, so it is left to Summarize.
+	if err := os.Symlink("a.txt", filepath.Join(dir, "data", "link")); err != nil {
+		t.Fatal(err)
+	}
+	node, err := getUnixFileNode(filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	summer := &checksummer{}
+	if _, err := client.AddNode(ctx, summer.wrap("", node)); err != nil {
+		t.Fatal(err)
+	} else if _, ok := summer.summary(); ok {
+		t.Errorf("expected a version with a symlink not to be summarized while adding")
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 
@@ -15,12 +18,18 @@ import (
 )
 
 type Config struct {
-	User         *UserConfig     `toml:"user,omitempty"`
-	Editor       string          `toml:"editor,omitempty"`
-	RemoteString string          `toml:"remote,omitempty"`
-	Ipfs         *IpfsConfig     `toml:"ipfs,omitempty"`
-	Database     *DatabaseConfig `toml:"database,omitempty"`
-	Remote       *Remote         `toml:"-"`
+	User         *UserConfig                      `toml:"user,omitempty"`
+	Editor       string                           `toml:"editor,omitempty"`
+	RemoteString string                           `toml:"remote,omitempty"`
+	Ipfs         *IpfsConfig                      `toml:"ipfs,omitempty"`
+	Database     *DatabaseConfig                  `toml:"database,omitempty"`
+	Checkout     *CheckoutConfig                  `toml:"checkout,omitempty"`
+	Retention    *RetentionConfig                 `toml:"retention,omitempty"`
+	Pinning      map[string]*PinningServiceConfig `toml:"pinning,omitempty"`
+	Dataset      *DatasetConfig                   `toml:"dataset,omitempty"`
+	Checksums    *ChecksumConfig                  `toml:"checksums,omitempty"`
+	Quota        *QuotaConfig                     `toml:"quota,omitempty"`
+	Remote       *Remote                          `toml:"-"`
 }
 
 type Remote struct {
@@ -82,6 +91,24 @@ type IpfsConfig struct {
 	Global bool   `toml:"global"`
 	Host   string `toml:"host,omitempty"`
 	Port   int    `toml:"port,omitempty"`
+	Shared bool   `toml:"shared,omitempty"`
+	NoCopy bool   `toml:"nocopy,omitempty"`
+	// LockTimeout is how long to wait for another process to release the
+	// IPFS repository, such as "30s" or "10m".
+	LockTimeout string `toml:"lock_timeout,omitempty"`
+}
+
+const DefaultRepoLockTimeout = 5 * time.Minute
+
+func (c IpfsConfig) RepoLockTimeout() (time.Duration, error) {
+	if c.LockTimeout == "" {
+		return DefaultRepoLockTimeout, nil
+	}
+	timeout, err := time.ParseDuration(c.LockTimeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid ipfs.lock_timeout %q", c.LockTimeout)
+	}
+	return timeout, nil
 }
 
 func (c IpfsConfig) Url() string {
@@ -105,6 +132,46 @@ type DatabaseConfig struct {
 	Path string `toml:"path"`
 }
 
+type CheckoutConfig struct {
+	Workers int `toml:"workers,omitempty"`
+}
+
+// RetentionConfig decides which versions `dorothy gc` keeps pinned. A version
+// is kept if any of the rules keeps it.
+type RetentionConfig struct {
+	KeepLast      int    `toml:"keep_last,omitempty"`
+	KeepTagged    bool   `toml:"keep_tagged,omitempty"`
+	KeepNewerThan string `toml:"keep_newer_than,omitempty"`
+}
+
+// PinningServiceConfig describes an IPFS Pinning Service API endpoint. Unless
+// NoPush is set, every version is pinned to the service on `dorothy push`.
+type PinningServiceConfig struct {
+	Endpoint string `toml:"endpoint"`
+	Token    string `toml:"token,omitempty"`
+	NoPush   bool   `toml:"nopush,omitempty"`
+}
+
+// DatasetConfig holds metadata about the dataset which the manifest does not
+// record, for use in descriptions such as RO-Crates.
+type DatasetConfig struct {
+	Name        string `toml:"name,omitempty"`
+	Description string `toml:"description,omitempty"`
+	License     string `toml:"license,omitempty"`
+}
+
+// ChecksumConfig chooses which checksums are recorded for each file when a
+// version is committed. sha256 checksums are always recorded.
+type ChecksumConfig struct {
+	Md5 bool `toml:"md5,omitempty"`
+}
+
+// QuotaConfig limits how much a server stores for each dataset, as a size
+// such as "500MB" or "10GiB", counting the full size of every version.
+type QuotaConfig struct {
+	Dataset string `toml:"dataset,omitempty"`
+}
+
 func (u *UserConfig) String() string {
 	s := u.Name
 	if s != "" {
@@ -174,3 +241,64 @@ func (config *Config) Encode(w io.Writer) error {
 	encoder := toml.NewEncoder(w)
 	return encoder.Encode(config)
 }
+
+// configKind returns the kind of the field of Config which props name, or
+// reflect.Invalid if Config has no such field.
+func configKind(props []string) reflect.Kind {
+	t := reflect.TypeOf(Config{})
+	for _, prop := range props {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			found := false
+			for i := 0; i < t.NumField(); i++ {
+				name, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+				if name == prop {
+					t, found = t.Field(i).Type, true
+					break
+				}
+			}
+			if !found {
+				return reflect.Invalid
+			}
+		default:
+			return reflect.Invalid
+		}
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+// parseConfigValue converts value to the type of the field of Config which
+// props name, so that the configuration can still be decoded once written.
+// Values of properties which Config does not know are promoted.
+func parseConfigValue(props []string, value string) (any, error) {
+	switch configKind(props) {
+	case reflect.Bool:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s must be true or false", strings.Join(props, "."))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s must be an integer", strings.Join(props, "."))
+	case reflect.Float32, reflect.Float64:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s must be a number", strings.Join(props, "."))
+	case reflect.String:
+		return value, nil
+	}
+	return promote(value), nil
+}
@@ -0,0 +1,326 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/ipfs/kubo/client/rpc"
+	"github.com/ipfs/kubo/core/corehttp"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	oldcmds "github.com/ipfs/kubo/commands"
+	kubo "github.com/ipfs/kubo/core"
+)
+
+const daemonProbeTimeout = 2 * time.Second
+
+// DaemonLauncher starts a daemon for the dataset in the background and waits
+// for it to accept connections. The command line tool sets it so that
+// datasets sharing an IPFS repository go through one daemon rather than
+// taking turns to open the repository. When it is nil, as in tests, the
+// repository is opened directly.
+var DaemonLauncher func(d *Dorothy) error
+
+type DaemonStatus struct {
+	Running  bool
+	Pid      int
+	Socket   string
+	Identity peer.ID
+}
+
+// DaemonSocketPath returns the unix socket on which a daemon serving the IPFS
+// repository used by the dataset in dir listens. Datasets which share an IPFS
+// repository also share a daemon.
+func (s *Ipfs) DaemonSocketPath(dir string) string {
+	sum := sha256.Sum256([]byte(s.repoPath(dir)))
+	return filepath.Join(xdg.RuntimeDir, "dorothy", hex.EncodeToString(sum[:8])+".sock")
+}
+
+func daemonFile(socket, ext string) string {
+	return strings.TrimSuffix(socket, ".sock") + ext
+}
+
+func DaemonLogPath(socket string) string {
+	return daemonFile(socket, ".log")
+}
+
+func dialDaemon(ctx context.Context, socket string) (*rpc.HttpApi, peer.ID, error) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, "", err
+	}
+
+	api, err := rpc.NewURLApiWithClient("http://dorothy", &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+			DisableKeepAlives: true,
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, daemonProbeTimeout)
+	defer cancel()
+
+	var resp struct {
+		Identity peer.ID `json:"ID"`
+	}
+	if err := api.Request("id").Exec(ctx, &resp); err != nil {
+		return nil, "", err
+	}
+
+	return api, resp.Identity, nil
+}
+
+// connectDaemon connects to a running daemon for the repository, if there is
+// one, and reports whether it did.
+func (s *Ipfs) connectDaemon(ctx context.Context, dir string) bool {
+	api, id, err := dialDaemon(ctx, s.DaemonSocketPath(dir))
+	if err != nil {
+		return false
+	}
+
+	s.CoreAPI = api
+	s.Identity = id
+	return true
+}
+
+func (d *Dorothy) DaemonStatus() DaemonStatus {
+	status := DaemonStatus{
+		Socket: d.Ipfs.DaemonSocketPath(d.Directory),
+	}
+
+	if _, id, err := dialDaemon(d, status.Socket); err == nil {
+		status.Running = true
+		status.Identity = id
+	}
+
+	if pid, err := os.ReadFile(daemonFile(status.Socket, ".pid")); err == nil {
+		status.Pid, _ = strconv.Atoi(strings.TrimSpace(string(pid)))
+	}
+
+	return status
+}
+
+// startSharedDaemon launches a daemon for a shared IPFS repository unless one
+// is already running. Launching is serialized across processes so that only
+// one daemon is started. It reports whether a daemon is running afterwards.
+func (d *Dorothy) startSharedDaemon() bool {
+	if DaemonLauncher == nil || !d.Ipfs.config.Shared || !d.IsInitialized() {
+		return false
+	} else if d.DaemonStatus().Running {
+		return true
+	}
+
+	timeout, err := d.Ipfs.config.RepoLockTimeout()
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(d, timeout)
+	defer cancel()
+
+	unlock, err := waitForLock(ctx, SharedIpfsPath(), "daemon.lock")
+	if err != nil {
+		return false
+	}
+	defer unlock.Close()
+
+	if d.DaemonStatus().Running {
+		return true
+	}
+	if err := DaemonLauncher(d); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot start a daemon for the shared IPFS repository: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// idleListener closes itself once it has had no open connections for the
+// idle timeout, which stops the server using it.
+type idleListener struct {
+	net.Listener
+	sync.Mutex
+	idle    time.Duration
+	active  int
+	timer   *time.Timer
+	expired bool
+}
+
+func newIdleListener(listener net.Listener, idle time.Duration) *idleListener {
+	l := &idleListener{Listener: listener, idle: idle}
+	l.timer = time.AfterFunc(idle, l.expire)
+	return l
+}
+
+func (l *idleListener) expire() {
+	l.Lock()
+	defer l.Unlock()
+	if l.active == 0 {
+		l.expired = true
+		l.Listener.Close()
+	}
+}
+
+func (l *idleListener) Expired() bool {
+	l.Lock()
+	defer l.Unlock()
+	return l.expired
+}
+
+func (l *idleListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	l.active++
+	l.timer.Stop()
+	return &idleConn{Conn: conn, listener: l}, nil
+}
+
+func (l *idleListener) release() {
+	l.Lock()
+	defer l.Unlock()
+	if l.active--; l.active == 0 {
+		l.timer.Reset(l.idle)
+	}
+}
+
+type idleConn struct {
+	net.Conn
+	listener *idleListener
+	once     sync.Once
+}
+
+func (c *idleConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.listener.release)
+	return err
+}
+
+// RunDaemon starts an IPFS node for the dataset and serves the IPFS HTTP API
+// on a unix socket until the dorothy context is cancelled or, if idle is
+// positive, until no command has used it for that long. Other dorothy
+// commands use the daemon automatically while it is running.
+func (d *Dorothy) RunDaemon(idle time.Duration, options ...IpfsNodeOption) error {
+	if !d.IsInitialized() {
+		return fmt.Errorf("not a dorothy repository")
+	} else if d.Config.Ipfs != nil && d.Config.Ipfs.Global {
+		return fmt.Errorf("dataset uses a global IPFS instance; no daemon is needed")
+	}
+
+	socket := d.Ipfs.DaemonSocketPath(d.Directory)
+	if status := d.DaemonStatus(); status.Running {
+		return fmt.Errorf("daemon already running (pid %d)", status.Pid)
+	}
+
+	repo := d.Ipfs.repoPath(d.Directory)
+	if err := d.Ipfs.connectLocal(d, repo, options...); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return err
+	}
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var listener net.Listener
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	var idleness *idleListener
+	if idle > 0 {
+		idleness = newIdleListener(listener, idle)
+		listener = idleness
+	}
+
+	pidfile := daemonFile(socket, ".pid")
+	if err := os.WriteFile(pidfile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		listener.Close()
+		return err
+	}
+	defer os.Remove(pidfile)
+
+	go func() {
+		<-d.Done()
+		listener.Close()
+	}()
+
+	cctx := oldcmds.Context{
+		ConfigRoot: repo,
+		ReqLog:     &oldcmds.ReqLog{},
+		ConstructNode: func() (*kubo.IpfsNode, error) {
+			return d.Ipfs.node, nil
+		},
+	}
+
+	err = corehttp.Serve(d.Ipfs.node, listener, corehttp.CommandsOption(cctx))
+	if d.Err() != nil || (idleness != nil && idleness.Expired()) {
+		return d.Ipfs.node.Close()
+	}
+	return err
+}
+
+// StopDaemon asks a running daemon to shut down and waits for it to exit.
+func (d *Dorothy) StopDaemon() error {
+	status := d.DaemonStatus()
+	if !status.Running {
+		return fmt.Errorf("daemon is not running")
+	} else if status.Pid == 0 {
+		return fmt.Errorf("cannot determine the daemon's process id")
+	}
+
+	process, err := os.FindProcess(status.Pid)
+	if err != nil {
+		return err
+	}
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+
+	return d.waitForDaemon(false, 30*time.Second)
+}
+
+// waitForDaemon polls until the daemon is running or has stopped.
+func (d *Dorothy) waitForDaemon(running bool, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if d.DaemonStatus().Running == running {
+			return nil
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	if running {
+		return errors.New("timed out waiting for the daemon to start")
+	}
+	return errors.New("timed out waiting for the daemon to stop")
+}
+
+// WaitForDaemon waits for a newly started daemon to accept connections.
+func (d *Dorothy) WaitForDaemon(timeout time.Duration) error {
+	return d.waitForDaemon(true, timeout)
+}
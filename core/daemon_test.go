@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/ipfs/kubo/client/rpc"
+)
+
+func newDaemonTestDorothy(t *testing.T, ctx context.Context) *Dorothy {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	d := &Dorothy{
+		Context:   ctx,
+		Directory: filepath.Join(t.TempDir(), ".dorothy"),
+		Ipfs:      NewIpfs(nil),
+	}
+	if err := os.Mkdir(d.Directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{d.LocalConfigPath(), d.ManifestPath()} {
+		if err := os.WriteFile(filename, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.InitializeIpfs(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDaemon(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := newDaemonTestDorothy(t, ctx)
+	if d.DaemonStatus().Running {
+		t.Fatal("expected the daemon not to be running")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.RunDaemon(0, IpfsOffline)
+	}()
+	if err := d.WaitForDaemon(30 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	status := d.DaemonStatus()
+	if status.Pid != os.Getpid() || status.Identity != d.Ipfs.Identity {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	client := NewIpfs(nil)
+	if err := client.Connect(context.Background(), d.Directory, IpfsOffline); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.CoreAPI.(*rpc.HttpApi); !ok {
+		t.Fatalf("expected to connect through the daemon, got %T", client.CoreAPI)
+	}
+
+	manifest, err := client.CreateEmptyManifest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Ipfs.GetManifest(ctx, manifest.Hash); err != nil {
+		t.Errorf("expected the daemon's node to have the manifest: %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for the daemon to stop")
+	}
+
+	if _, err := os.Stat(status.Socket); !os.IsNotExist(err) {
+		t.Errorf("expected the socket to be removed")
+	}
+}
+
+func TestDaemonIdleTimeout(t *testing.T) {
+	d := newDaemonTestDorothy(t, context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.RunDaemon(500*time.Millisecond, IpfsOffline)
+	}()
+	if err := d.WaitForDaemon(30 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("expected the idle daemon to stop")
+	}
+
+	if d.DaemonStatus().Running {
+		t.Errorf("expected the daemon to have stopped")
+	}
+}
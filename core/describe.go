@@ -0,0 +1,420 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type DescribeFormat string
+
+const (
+	DescribeFormatRoCrate     DescribeFormat = "ro-crate"
+	DescribeFormatDataPackage DescribeFormat = "datapackage"
+)
+
+var AllDescribeFormat = []DescribeFormat{
+	DescribeFormatRoCrate,
+	DescribeFormatDataPackage,
+}
+
+func (f DescribeFormat) IsValid() bool {
+	switch f {
+	case DescribeFormatRoCrate, DescribeFormatDataPackage:
+		return true
+	}
+	return false
+}
+
+func (f DescribeFormat) String() string {
+	return string(f)
+}
+
+// Filename is the name that consumers of the format expect the description
+// to have.
+func (f DescribeFormat) Filename() string {
+	switch f {
+	case DescribeFormatRoCrate:
+		return "ro-crate-metadata.json"
+	case DescribeFormatDataPackage:
+		return "datapackage.json"
+	}
+	return ""
+}
+
+func (f DescribeFormat) ContentType() string {
+	if f == DescribeFormatRoCrate {
+		return "application/ld+json"
+	}
+	return "application/json"
+}
+
+// DatasetInfo is the metadata about a dataset which the manifest does not
+// record.
+type DatasetInfo struct {
+	Name        string
+	Description string
+	License     string
+	Url         string
+}
+
+type Person struct {
+	Name  string
+	Email string
+}
+
+type DescribedFile struct {
+	TreeEntry
+	MediaType string
+}
+
+// Description gathers what is known about a version of a dataset.
+type Description struct {
+	Info    DatasetInfo
+	Version *Version
+	// Authors are the authors of the version and its ancestors, most recent
+	// first.
+	Authors []Person
+	Files   []DescribedFile
+}
+
+// dataMediaTypes covers common data formats which are missing from the
+// standard library's table, so that descriptions do not depend on the
+// system's mime.types.
+var dataMediaTypes = map[string]string{
+	".csv":     "text/csv",
+	".tsv":     "text/tab-separated-values",
+	".txt":     "text/plain",
+	".md":      "text/markdown",
+	".json":    "application/json",
+	".yaml":    "application/yaml",
+	".yml":     "application/yaml",
+	".xml":     "application/xml",
+	".nc":      "application/x-netcdf",
+	".h5":      "application/x-hdf5",
+	".hdf5":    "application/x-hdf5",
+	".parquet": "application/vnd.apache.parquet",
+	".zip":     "application/zip",
+	".gz":      "application/gzip",
+	".tar":     "application/x-tar",
+	".zst":     "application/zstd",
+	".pdf":     "application/pdf",
+	".png":     "image/png",
+	".jpg":     "image/jpeg",
+	".jpeg":    "image/jpeg",
+	".tif":     "image/tiff",
+	".tiff":    "image/tiff",
+}
+
+func mediaTypeOf(filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if t, ok := dataMediaTypes[ext]; ok {
+		return t
+	} else if t, _, err := mime.ParseMediaType(mime.TypeByExtension(ext)); err == nil {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func parsePerson(author string) Person {
+	if address, err := mail.ParseAddress(author); err == nil {
+		return Person{Name: address.Name, Email: address.Address}
+	}
+	return Person{Name: author}
+}
+
+// licenseUrl returns the URL of a license given either as a URL or as an SPDX
+// identifier.
+func licenseUrl(license string) string {
+	if strings.Contains(license, "://") {
+		return license
+	}
+	return "https://spdx.org/licenses/" + license
+}
+
+// Describe gathers the description of a version of the manifest.
+func (s *Ipfs) Describe(ctx context.Context, manifest *Manifest, version *Version, info DatasetInfo) (*Description, error) {
+	entries, err := s.Ls(ctx, version.Hash, "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &Description{Info: info, Version: version}
+	for _, entry := range entries {
+		file := DescribedFile{TreeEntry: entry}
+		if !entry.IsDir() {
+			file.MediaType = mediaTypeOf(entry.Path)
+		}
+		desc.Files = append(desc.Files, file)
+	}
+
+	ancestors := manifest.Ancestors(version.Hash)
+	seen := make(map[string]bool)
+	for _, v := range append([]*Version{version}, manifest.ReverseVersions()...) {
+		if !ancestors[v.Hash] || v.Author == "" || seen[v.Author] {
+			continue
+		}
+		seen[v.Author] = true
+		desc.Authors = append(desc.Authors, parsePerson(v.Author))
+	}
+
+	return desc, nil
+}
+
+func (desc *Description) Write(w io.Writer, format DescribeFormat) error {
+	var document any
+	switch format {
+	case DescribeFormatRoCrate:
+		document = desc.roCrate()
+	case DescribeFormatDataPackage:
+		document = desc.dataPackage()
+	default:
+		return fmt.Errorf("unsupported description format %q", format)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func (p Person) id() string {
+	if p.Email != "" {
+		return "mailto:" + p.Email
+	}
+	return "#" + strings.ReplaceAll(p.Name, " ", "-")
+}
+
+// roCrate builds an RO-Crate 1.1 metadata document.
+func (desc *Description) roCrate() map[string]any {
+	root := map[string]any{
+		"@id":           "./",
+		"@type":         "Dataset",
+		"name":          desc.Info.Name,
+		"identifier":    "ipfs://" + desc.Version.Hash,
+		"version":       desc.Version.Hash,
+		"datePublished": desc.Version.Date.Format(time.RFC3339),
+	}
+	if desc.Info.Description != "" {
+		root["description"] = desc.Info.Description
+	} else if desc.Version.Message != "" {
+		root["description"] = desc.Version.Message
+	}
+	if desc.Info.Url != "" {
+		root["url"] = desc.Info.Url
+	}
+
+	graph := []map[string]any{
+		{
+			"@id":        "ro-crate-metadata.json",
+			"@type":      "CreativeWork",
+			"conformsTo": map[string]string{"@id": "https://w3id.org/ro/crate/1.1"},
+			"about":      map[string]string{"@id": "./"},
+		},
+		root,
+	}
+
+	if desc.Info.License != "" {
+		root["license"] = map[string]string{"@id": licenseUrl(desc.Info.License)}
+		graph = append(graph, map[string]any{
+			"@id":        licenseUrl(desc.Info.License),
+			"@type":      "CreativeWork",
+			"name":       desc.Info.License,
+			"identifier": desc.Info.License,
+		})
+	}
+
+	var authors []map[string]string
+	for _, author := range desc.Authors {
+		authors = append(authors, map[string]string{"@id": author.id()})
+		person := map[string]any{"@id": author.id(), "@type": "Person", "name": author.Name}
+		if author.Email != "" {
+			person["email"] = author.Email
+		}
+		graph = append(graph, person)
+	}
+	if len(authors) != 0 {
+		root["author"] = authors
+	}
+
+	// Each file is part of the directory which contains it, and the
+	// top-level ones of the root.
+	dirs := map[string]map[string]any{".": root}
+	parts := make(map[string][]map[string]string)
+	for _, file := range desc.Files {
+		entity := map[string]any{
+			"@id":        file.Path,
+			"name":       path.Base(file.Path),
+			"identifier": "ipfs://" + file.Cid,
+		}
+		if file.IsDir() {
+			entity["@id"] = file.Path + "/"
+			entity["@type"] = "Dataset"
+			dirs[file.Path] = entity
+		} else {
+			entity["@type"] = "File"
+			entity["contentSize"] = fmt.Sprint(file.Size)
+			entity["encodingFormat"] = file.MediaType
+		}
+		parent := path.Dir(file.Path)
+		parts[parent] = append(parts[parent], map[string]string{"@id": entity["@id"].(string)})
+		graph = append(graph, entity)
+	}
+	for dir, entity := range dirs {
+		if len(parts[dir]) != 0 {
+			entity["hasPart"] = parts[dir]
+		}
+	}
+
+	return map[string]any{
+		"@context": "https://w3id.org/ro/crate/1.1/context",
+		"@graph":   graph,
+	}
+}
+
+var dataPackageNameRegexp = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// dataPackageName converts s to the lowercase form that Data Package and
+// resource names must take.
+func dataPackageName(s string) string {
+	return strings.Trim(dataPackageNameRegexp.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+type dataPackageLicense struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type dataPackageContributor struct {
+	Title string `json:"title"`
+	Email string `json:"email,omitempty"`
+	Role  string `json:"role"`
+}
+
+type dataPackageResource struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Format    string `json:"format,omitempty"`
+	MediaType string `json:"mediatype"`
+	Bytes     uint64 `json:"bytes"`
+}
+
+type dataPackage struct {
+	Profile      string                   `json:"profile"`
+	Name         string                   `json:"name"`
+	Id           string                   `json:"id"`
+	Title        string                   `json:"title,omitempty"`
+	Description  string                   `json:"description,omitempty"`
+	Homepage     string                   `json:"homepage,omitempty"`
+	Version      string                   `json:"version"`
+	Created      string                   `json:"created"`
+	Licenses     []dataPackageLicense     `json:"licenses,omitempty"`
+	Contributors []dataPackageContributor `json:"contributors,omitempty"`
+	Resources    []dataPackageResource    `json:"resources"`
+}
+
+// dataPackage builds a Frictionless Data Package descriptor.
+func (desc *Description) dataPackage() dataPackage {
+	pkg := dataPackage{
+		Profile:     "data-package",
+		Name:        dataPackageName(desc.Info.Name),
+		Id:          "ipfs://" + desc.Version.Hash,
+		Title:       desc.Info.Name,
+		Description: desc.Info.Description,
+		Homepage:    desc.Info.Url,
+		Version:     desc.Version.Hash,
+		Created:     desc.Version.Date.Format(time.RFC3339),
+		Resources:   []dataPackageResource{},
+	}
+	if pkg.Description == "" {
+		pkg.Description = desc.Version.Message
+	}
+	if pkg.Name == "" {
+		pkg.Name = strings.ToLower(desc.Version.Hash)
+	}
+
+	if desc.Info.License != "" {
+		pkg.Licenses = []dataPackageLicense{{Name: desc.Info.License, Path: licenseUrl(desc.Info.License)}}
+	}
+
+	for _, author := range desc.Authors {
+		title := author.Name
+		if title == "" {
+			title = author.Email
+		}
+		pkg.Contributors = append(pkg.Contributors, dataPackageContributor{
+			Title: title,
+			Email: author.Email,
+			Role:  "author",
+		})
+	}
+
+	names := make(map[string]int)
+	for _, file := range desc.Files {
+		if file.IsDir() {
+			continue
+		}
+
+		name := dataPackageName(file.Path)
+		if names[name]++; names[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, names[name])
+		}
+		pkg.Resources = append(pkg.Resources, dataPackageResource{
+			Name:      name,
+			Path:      file.Path,
+			Format:    strings.TrimPrefix(strings.ToLower(path.Ext(file.Path)), "."),
+			MediaType: file.MediaType,
+			Bytes:     file.Size,
+		})
+	}
+
+	return pkg
+}
+
+// DatasetInfo gathers the metadata about the dataset from the configuration,
+// falling back on the remote or the repository's directory for its name.
+func (d *Dorothy) DatasetInfo() DatasetInfo {
+	var info DatasetInfo
+	if d.Config.Dataset != nil {
+		info.Name = d.Config.Dataset.Name
+		info.Description = d.Config.Dataset.Description
+		info.License = d.Config.Dataset.License
+	}
+	if d.Config.Remote != nil {
+		if info.Name == "" {
+			info.Name = d.Config.Remote.Dataset
+		}
+		info.Url = d.Config.Remote.UrlString()
+	}
+	if info.Name == "" && d.Directory != "" {
+		info.Name = filepath.Base(filepath.Dir(d.Directory))
+	}
+	return info
+}
+
+// Describe writes a description of a version to w in the given format.
+func (d *Dorothy) Describe(rev string, format DescribeFormat, w io.Writer) error {
+	if !d.Ipfs.IsConnected() {
+		return fmt.Errorf("not connected to IPFS")
+	} else if !format.IsValid() {
+		return fmt.Errorf("unsupported description format %q", format)
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return err
+	}
+
+	desc, err := d.Ipfs.Describe(d, d.Manifest, version, d.DatasetInfo())
+	if err != nil {
+		return err
+	}
+	return desc.Write(w, format)
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMediaTypeOf(t *testing.T) {
+	for filename, expected := range map[string]string{
+		"data.csv":     "text/csv",
+		"sub/DATA.CSV": "text/csv",
+		"image.png":    "image/png",
+		"README":       "application/octet-stream",
+	} {
+		if got := mediaTypeOf(filename); got != expected {
+			t.Errorf("%s: expected %q, got %q", filename, expected, got)
+		}
+	}
+}
+
+func TestDataPackageName(t *testing.T) {
+	for s, expected := range map[string]string{
+		"Field Data 2024":   "field-data-2024",
+		"sub/readings.csv":  "sub-readings.csv",
+		"  Ünïcode & more ": "n-code-more",
+	} {
+		if got := dataPackageName(s); got != expected {
+			t.Errorf("%q: expected %q, got %q", s, expected, got)
+		}
+	}
+}
+
+func describeTestVersion(t *testing.T) (*Description, string) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.csv": "1,2\n", "sub/b.txt": "bb"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := client.Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &Manifest{Versions: []*Version{
+		{Author: "Jane Doe <jane@example.org>", Date: time.Now(), Message: "first", Hash: "parent", PathType: PathTypeDirectory},
+		{Author: "Unrelated <u@example.org>", Date: time.Now(), Message: "other", Hash: "other", PathType: PathTypeDirectory},
+		{Author: "John Doe <john@example.org>", Date: time.Now(), Message: "second", Hash: hash, PathType: PathTypeDirectory, Parents: []string{"parent"}},
+	}}
+
+	desc, err := client.Describe(ctx, manifest, manifest.Versions[2], DatasetInfo{Name: "Field Data", License: "CC-BY-4.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return desc, hash
+}
+
+func TestDescribeRoCrate(t *testing.T) {
+	desc, hash := describeTestVersion(t)
+
+	if len(desc.Authors) != 2 || desc.Authors[0].Email != "john@example.org" || desc.Authors[1].Email != "jane@example.org" {
+		t.Errorf("expected the authors of the version and its ancestors, got %+v", desc.Authors)
+	}
+
+	var buffer bytes.Buffer
+	if err := desc.Write(&buffer, DescribeFormatRoCrate); err != nil {
+		t.Fatal(err)
+	}
+
+	var crate struct {
+		Context string           `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &crate); err != nil {
+		t.Fatal(err)
+	}
+	entities := make(map[string]map[string]any)
+	for _, entity := range crate.Graph {
+		entities[entity["@id"].(string)] = entity
+	}
+
+	root, ok := entities["./"]
+	if !ok {
+		t.Fatalf("expected a root data entity, got %v", crate.Graph)
+	}
+	if root["identifier"] != "ipfs://"+hash || root["name"] != "Field Data" || root["description"] != "second" {
+		t.Errorf("unexpected root data entity %v", root)
+	}
+	if license := root["license"].(map[string]any); license["@id"] != "https://spdx.org/licenses/CC-BY-4.0" {
+		t.Errorf("unexpected license %v", license)
+	}
+	if parts := root["hasPart"].([]any); len(parts) != 2 {
+		t.Errorf("expected the top-level files to be parts of the root, got %v", parts)
+	}
+
+	file, ok := entities["sub/b.txt"]
+	if !ok {
+		t.Fatalf("expected an entity for every file, got %v", crate.Graph)
+	}
+	if file["@type"] != "File" || file["contentSize"] != "2" || file["encodingFormat"] != "text/plain" {
+		t.Errorf("unexpected file entity %v", file)
+	}
+	if sub := entities["sub/"]; sub == nil || len(sub["hasPart"].([]any)) != 1 {
+		t.Errorf("expected the file to be part of its directory, got %v", sub)
+	}
+	if _, ok := entities["mailto:jane@example.org"]; !ok {
+		t.Errorf("expected a person entity for each author")
+	}
+}
+
+func TestDescribeDataPackage(t *testing.T) {
+	desc, hash := describeTestVersion(t)
+
+	var buffer bytes.Buffer
+	if err := desc.Write(&buffer, DescribeFormatDataPackage); err != nil {
+		t.Fatal(err)
+	}
+
+	var pkg dataPackage
+	if err := json.Unmarshal(buffer.Bytes(), &pkg); err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Name != "field-data" || pkg.Version != hash || pkg.Id != "ipfs://"+hash {
+		t.Errorf("unexpected package %+v", pkg)
+	}
+	if len(pkg.Licenses) != 1 || pkg.Licenses[0].Name != "CC-BY-4.0" {
+		t.Errorf("unexpected licenses %+v", pkg.Licenses)
+	}
+	if len(pkg.Contributors) != 2 || pkg.Contributors[0].Title != "John Doe" {
+		t.Errorf("unexpected contributors %+v", pkg.Contributors)
+	}
+	if len(pkg.Resources) != 2 {
+		t.Fatalf("expected a resource for every file, got %+v", pkg.Resources)
+	}
+	csv := pkg.Resources[0]
+	if csv.Name != "a.csv" || csv.Path != "a.csv" || csv.Format != "csv" || csv.MediaType != "text/csv" || csv.Bytes != 4 {
+		t.Errorf("unexpected resource %+v", csv)
+	}
+}
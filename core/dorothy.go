@@ -5,16 +5,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/39alpha/dorothy/sdk"
 	"github.com/BurntSushi/toml"
 	"github.com/adrg/xdg"
+	"github.com/ipfs/boxo/files"
 	"github.com/ipfs/kubo/core/coreiface/options"
 )
 
@@ -27,6 +30,10 @@ type Dorothy struct {
 	Manifest      *Manifest
 
 	sdkClient *sdk.Client
+
+	// legacySizes caches the sizes of versions which record none, by hash,
+	// so that the quota does not walk them on every push.
+	legacySizes sync.Map
 }
 
 func NewDorothy() (*Dorothy, error) {
@@ -57,6 +64,10 @@ func (d *Dorothy) Setup(options ...IpfsNodeOption) error {
 		return err
 	}
 
+	if err := d.register(); err != nil {
+		return fmt.Errorf("cannot register dataset: %v", err)
+	}
+
 	return d.LoadManifest()
 }
 
@@ -172,12 +183,62 @@ func (d *Dorothy) LoadManifest() error {
 	return err
 }
 
-func (d *Dorothy) WriteManifestFile() error {
+// WriteManifestFile points the repository at the loaded manifest and records
+// the change in the reflog.
+func (d *Dorothy) WriteManifestFile(operation, message string) error {
 	if d.Manifest == nil {
 		return fmt.Errorf("no manifest loaded")
 	}
 
-	return os.WriteFile(d.ManifestPath(), []byte(d.Manifest.Hash), 0755)
+	old, err := os.ReadFile(d.ManifestPath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := writeFileAtomic(d.ManifestPath(), []byte(d.Manifest.Hash), 0755); err != nil {
+		return err
+	}
+
+	return d.appendReflog(ReflogEntry{
+		Old:       string(old),
+		New:       d.Manifest.Hash,
+		Date:      time.Now(),
+		Operation: operation,
+		Message:   message,
+	})
+}
+
+// MergeManifest merges incoming into the latest manifest while holding the
+// repository lock. The manifest is reloaded first if another process has
+// updated it since it was loaded, so that no versions are lost.
+func (d *Dorothy) MergeManifest(incoming *Manifest, operation, message string) ([]Conflict, error) {
+	return d.mergeManifest(incoming, operation, message, nil)
+}
+
+func (d *Dorothy) mergeManifest(incoming *Manifest, operation, message string, nopin map[string]bool) ([]Conflict, error) {
+	lock, err := d.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	hash, err := os.ReadFile(d.ManifestPath())
+	if err != nil {
+		return nil, err
+	}
+	if d.Manifest == nil || d.Manifest.Hash != string(hash) {
+		if err := d.LoadManifest(); err != nil {
+			return nil, err
+		}
+	}
+
+	merged, conflicts, err := d.Ipfs.mergeAndCommit(d, d.Manifest, incoming, nopin)
+	if err != nil || len(conflicts) != 0 {
+		return conflicts, err
+	}
+
+	d.Manifest = merged
+	return nil, d.WriteManifestFile(operation, message)
 }
 
 func (d *Dorothy) InitializeIpfs() error {
@@ -185,6 +246,7 @@ func (d *Dorothy) InitializeIpfs() error {
 }
 
 func (d *Dorothy) ConnectIpfs(options ...IpfsNodeOption) error {
+	d.startSharedDaemon()
 	return d.Ipfs.Connect(d, d.Directory, options...)
 }
 
@@ -204,11 +266,18 @@ func (d *Dorothy) InitializeAndConnectIpfs(options ...IpfsNodeOption) error {
 	return nil
 }
 
-func (d *Dorothy) Initialize(global bool, options ...IpfsNodeOption) error {
+func (d *Dorothy) Initialize(global, shared bool, options ...IpfsNodeOption) error {
 	if d.IsInitialized() {
 		return d.InitializeAndConnectIpfs(options...)
 	}
 
+	if d.Config.Ipfs != nil && d.Config.Ipfs.Shared && !global {
+		shared = true
+	}
+	if global && shared {
+		return fmt.Errorf("cannot use both a global IPFS instance and a shared IPFS repo")
+	}
+
 	if err := os.MkdirAll(d.Directory, 0755); err != nil {
 		if os.IsExist(err) {
 			return fmt.Errorf("dorothy already initialized")
@@ -228,6 +297,16 @@ func (d *Dorothy) Initialize(global bool, options ...IpfsNodeOption) error {
 			d.Config.Ipfs.Global = true
 		}
 		d.Ipfs = NewIpfs(d.Config.Ipfs)
+	} else if shared {
+		config.Ipfs = &IpfsConfig{
+			Shared: true,
+		}
+		if d.Config.Ipfs == nil {
+			d.Config.Ipfs = config.Ipfs
+		} else {
+			d.Config.Ipfs.Shared = true
+		}
+		d.Ipfs = NewIpfs(d.Config.Ipfs)
 	}
 
 	if err := (&config).WriteFile(d.LocalConfigPath()); err != nil {
@@ -248,10 +327,14 @@ func (d *Dorothy) Initialize(global bool, options ...IpfsNodeOption) error {
 		return err
 	}
 
-	if err := d.WriteManifestFile(); err != nil {
+	if err := d.WriteManifestFile(ReflogInit, ""); err != nil {
 		return fmt.Errorf("failed to write manifest file: %v", err)
 	}
 
+	if err := d.register(); err != nil {
+		return fmt.Errorf("cannot register dataset: %v", err)
+	}
+
 	return nil
 }
 
@@ -289,11 +372,16 @@ func (d *Dorothy) SetConfig(props []string, value string, global bool) (string,
 		m = map[string]any{}
 	}
 
+	parsed, err := parseConfigValue(props, value)
+	if err != nil {
+		return "", err
+	}
+
 	n := len(props)
 	s := m
 	for i, prop := range props {
 		if i == n-1 {
-			s[prop] = promote(value)
+			s[prop] = parsed
 			break
 		}
 
@@ -500,17 +588,26 @@ func (d *Dorothy) Fetch() ([]Conflict, error) {
 		return nil, err
 	}
 
-	merged, conflicts, err := d.Ipfs.MergeAndCommit(d, d.Manifest, manifest)
-	if err != nil || len(conflicts) != 0 {
-		return conflicts, err
-	}
-
-	d.Manifest = merged
-	return nil, d.WriteManifestFile()
+	return d.MergeManifest(manifest, ReflogFetch, d.Config.Remote.String())
 }
 
-func Clone(remote, dest string, global bool) (*Dorothy, error) {
-	if dest == "" {
+// Clone creates a repository from a remote or, if remote names a file, from
+// a bundle.
+func Clone(remote, dest string, global, shared bool) (*Dorothy, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var bundle string
+	if stat, err := os.Stat(remote); err == nil && !stat.IsDir() {
+		if bundle, err = filepath.Abs(remote); err != nil {
+			return nil, err
+		}
+		if dest == "" {
+			dest = strings.TrimSuffix(filepath.Base(remote), filepath.Ext(remote))
+		}
+	} else if dest == "" {
 		r, err := NewRemote(remote)
 		if err != nil {
 			return nil, err
@@ -519,13 +616,10 @@ func Clone(remote, dest string, global bool) (*Dorothy, error) {
 		dest = r.Dataset
 	}
 
-	cwd, err := os.Getwd()
-	if err != nil {
+	if dest, err = filepath.Abs(dest); err != nil {
 		return nil, err
 	}
 
-	dest = filepath.Join(cwd, dest)
-
 	if err := os.MkdirAll(dest, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create the repository directory %q", dest)
 	}
@@ -545,10 +639,14 @@ func Clone(remote, dest string, global bool) (*Dorothy, error) {
 		return nil, fmt.Errorf("directory already contains an initialized dataset")
 	}
 
-	if err := d.Initialize(global); err != nil {
+	if err := d.Initialize(global, shared); err != nil {
 		return d, err
 	}
 
+	if bundle != "" {
+		return d, d.cloneBundle(bundle)
+	}
+
 	if _, err := d.SetConfig([]string{"remote"}, remote, false); err != nil {
 		return d, err
 	}
@@ -563,35 +661,132 @@ func Clone(remote, dest string, global bool) (*Dorothy, error) {
 	return d, nil
 }
 
-func (d *Dorothy) Checkout(hash, dest string) error {
+func (d *Dorothy) cloneBundle(filename string) error {
+	handle, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	_, conflicts, err := d.FetchBundle(handle, filename)
+	if len(conflicts) != 0 {
+		return fmt.Errorf("clone encountered an unexpected dataset state")
+	}
+	return err
+}
+
+// findVersion finds the version with the given tag or hash prefix.
+func (d *Dorothy) findVersion(rev string) (*Version, error) {
+	tags, err := d.LoadTags()
+	if err != nil {
+		return nil, err
+	}
+	if tags[rev] != "" {
+		rev = tags[rev]
+	}
+	return d.Manifest.FindVersion(rev)
+}
+
+func (d *Dorothy) ResolveRevision(spec string) (*Version, string, error) {
+	if d.Manifest == nil {
+		return nil, "", fmt.Errorf("no manifest found")
+	}
+
+	rev, subpath, _ := strings.Cut(spec, ":")
+	version, err := d.findVersion(rev)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return version, subpath, nil
+}
+
+func (d *Dorothy) Summarize(rev string) (*Version, TreeSummary, error) {
 	if !d.Ipfs.IsConnected() {
-		return fmt.Errorf("not connected to IPFS")
+		return nil, TreeSummary{}, fmt.Errorf("not connected to IPFS")
 	}
 
 	if d.Manifest == nil {
-		return fmt.Errorf("no manifest found")
+		return nil, TreeSummary{}, fmt.Errorf("no manifest found")
 	}
 
-	for _, version := range d.Manifest.Versions {
-		if version.Hash == hash {
-			return d.Ipfs.Get(d, hash, dest)
+	version, err := d.findVersion(rev)
+	if err != nil {
+		return nil, TreeSummary{}, err
+	}
+
+	summary, err := d.Ipfs.VersionSummary(d, version)
+	return version, summary, err
+}
+
+func (d *Dorothy) Ls(spec string, recursive bool) ([]TreeEntry, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	version, subpath, err := d.ResolveRevision(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Ipfs.Ls(d, version.Hash, subpath, recursive)
+}
+
+func (d *Dorothy) Open(spec string) (io.ReadSeekCloser, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	version, subpath, err := d.ResolveRevision(spec)
+	if err != nil {
+		// Imported content can be read before it is committed.
+		rev, subpath, _ := strings.Cut(spec, ":")
+		if !d.isCompleteRoot(rev) {
+			return nil, err
 		}
+		return d.Ipfs.Open(d, rev, subpath)
 	}
 
-	var matches []*Version
-	for _, version := range d.Manifest.Versions {
-		if strings.HasPrefix(version.Hash, hash) {
-			matches = append(matches, version)
+	return d.Ipfs.Open(d, version.Hash, subpath)
+}
+
+func (d *Dorothy) Checkout(spec, dest string, options ...CheckoutOption) error {
+	if !d.Ipfs.IsConnected() {
+		return fmt.Errorf("not connected to IPFS")
+	}
+
+	version, subpath, err := d.ResolveRevision(spec)
+	if err != nil {
+		return err
+	}
+
+	// A destination with a recorded base version is a working tree, and
+	// stays one; any other destination is only tracked if asked to be.
+	base := d.BaseVersion(dest)
+	var baseAdd *AddSettings
+	if base != "" {
+		if baseVersion, err := d.Manifest.FindVersion(base); err == nil {
+			baseAdd = baseVersion.Add
 		}
 	}
+	options = append([]CheckoutOption{
+		CheckoutTrack(base != ""),
+		CheckoutBase(base),
+		CheckoutAddSettings(version.Add, baseAdd),
+	}, options...)
+	if d.Config.Checkout != nil {
+		options = append([]CheckoutOption{CheckoutWorkers(d.Config.Checkout.Workers)}, options...)
+	}
 
-	if len(matches) == 0 {
-		return fmt.Errorf("hash %q not found in manifest", hash)
-	} else if len(matches) == 1 {
-		return d.Ipfs.Get(d, matches[0].Hash, dest)
-	} else {
-		return fmt.Errorf("hash matches multiple commits; aborting")
+	cfg := NewCheckoutSettings(options...)
+	if err := d.Ipfs.Checkout(d, version.Hash, subpath, dest, cfg); err != nil {
+		return err
 	}
+
+	if cfg.Track && subpath == "" {
+		return d.SetBaseVersion(dest, version.Hash)
+	}
+	return nil
 }
 
 func (d *Dorothy) Push() ([]Conflict, error) {
@@ -635,16 +830,10 @@ func (d *Dorothy) Push() ([]Conflict, error) {
 		return nil, fmt.Errorf("failed to retrieve manifest after push: %v", err)
 	}
 
-	merged, conflicts, err := d.Ipfs.MergeAndCommit(d, d.Manifest, remote)
-	if err != nil || len(conflicts) != 0 {
-		return conflicts, err
-	}
-
-	d.Manifest = merged
-	return nil, d.WriteManifestFile()
+	return d.MergeManifest(remote, ReflogPush, d.Config.Remote.String())
 }
 
-func (d *Dorothy) Commit(paths []string, message string, nopin bool, parents []string) ([]Conflict, error) {
+func (d *Dorothy) Commit(paths []string, message string, nopin, nocopy bool, parents []string) ([]Conflict, error) {
 	if len(paths) == 0 {
 		return nil, nil
 	}
@@ -661,9 +850,25 @@ func (d *Dorothy) Commit(paths []string, message string, nopin bool, parents []s
 		return nil, fmt.Errorf("empty message; aborting")
 	}
 
+	nocopy = nocopy || (d.Config.Ipfs != nil && d.Config.Ipfs.NoCopy)
+	if nocopy && d.Config.Ipfs != nil && d.Config.Ipfs.Shared {
+		return nil, fmt.Errorf("--nocopy is not supported with a shared IPFS repo")
+	}
+	// The settings are recorded with the version so that local files can
+	// later be hashed the same way to compare them with it.
+	settings := DefaultAddSettings(nocopy)
+	addOptions, err := settings.options()
+	if err != nil {
+		return nil, err
+	}
+	addOptions = append(addOptions,
+		options.Unixfs.Pin(!nopin),
+		options.Unixfs.Progress(true),
+		options.Unixfs.Nocopy(nocopy),
+	)
+
 	var pathtype PathType
-	var hash string
-	var err error
+	var node files.Node
 
 	if len(paths) == 1 {
 		path := paths[0]
@@ -676,35 +881,71 @@ func (d *Dorothy) Commit(paths []string, message string, nopin bool, parents []s
 			pathtype = PathTypeFile
 		}
 
-		hash, err = d.Ipfs.Add(d, path, options.Unixfs.Pin(!nopin), options.Unixfs.Progress(true))
+		node, err = getUnixFileNode(path)
 	} else {
 		pathtype = PathTypeDirectory
-		hash, err = d.Ipfs.AddMany(d, paths, options.Unixfs.Pin(!nopin), options.Unixfs.Progress(true))
+		node, err = getUnixFileNodes(paths)
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to add dataset %v: %v", paths, err)
 	}
 
-	merged, conflicts, err := d.Ipfs.MergeAndCommit(d, d.Manifest, &Manifest{
+	// The files are checksummed as they are added, rather than read again.
+	summer := d.newChecksummer()
+	hash, err := d.Ipfs.AddNode(d, summer.wrap("", node), addOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add dataset %v: %v", paths, err)
+	}
+
+	checksums, err := d.recordChecksums(hash, summer, !nopin)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := d.recordSummary(hash, summer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merging pins every new version, so one committed without a pin has to
+	// be left out explicitly.
+	var unpinned map[string]bool
+	if nopin {
+		unpinned = map[string]bool{hash: true}
+	}
+
+	conflicts, err := d.mergeManifest(&Manifest{
 		Versions: []*Version{
 			{
-				Author:   d.Config.User.String(),
-				Date:     time.Now(),
-				Message:  message,
-				Hash:     hash,
-				PathType: pathtype,
-				Parents:  parents,
+				Author:    d.Config.User.String(),
+				Date:      time.Now(),
+				Message:   message,
+				Hash:      hash,
+				PathType:  pathtype,
+				Parents:   parents,
+				Checksums: checksums,
+				Summary:   summary,
+				Add:       settings,
 			},
 		},
-	})
+	}, ReflogCommit, message, unpinned)
 
 	if err != nil || len(conflicts) != 0 {
 		return conflicts, err
 	}
 
-	d.Manifest = merged
-	return nil, d.WriteManifestFile()
+	if len(paths) == 1 {
+		return nil, d.SetBaseVersion(paths[0], hash)
+	}
+	return nil, nil
+}
+
+func (d *Dorothy) VerifyFilestore() ([]FilestoreEntry, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+	return d.Ipfs.VerifyFilestore(d)
 }
 
 func (d *Dorothy) UnknownCommits(commits []string) []string {
@@ -757,5 +998,39 @@ func (d *Dorothy) Recieve(old *Manifest, hash string) (*Manifest, []Conflict, er
 		return nil, nil, err
 	}
 
-	return d.Ipfs.MergeAndCommit(d, old, new)
+	// The quota is checked before merging, since merging pins the new
+	// versions. It trusts the sizes the client recorded, which are checked
+	// once the versions have been fetched and walking them is cheap.
+	merged, conflicts, err := old.Merge(new)
+	if err != nil || len(conflicts) != 0 {
+		return nil, conflicts, err
+	} else if err := d.CheckQuota(merged); err != nil {
+		return nil, nil, err
+	}
+
+	delta, err := old.Diff(new)
+	if err != nil {
+		return nil, nil, err
+	}
+	pinned, err := d.Ipfs.pinnedSet(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest, conflicts, err := d.Ipfs.MergeAndCommit(d, old, new)
+	if err != nil || len(conflicts) != 0 {
+		return nil, conflicts, err
+	}
+
+	if err := d.CheckSummaries(old, new); err != nil {
+		// Only the pins this push added are removed, since the versions may
+		// also belong to other datasets.
+		for _, version := range delta {
+			if !pinned[version.Hash] {
+				d.Ipfs.unpinVersion(d, version, pinned[version.Checksums])
+			}
+		}
+		return nil, nil, err
+	}
+	return manifest, nil, nil
 }
@@ -0,0 +1,272 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/core/coreiface/options"
+
+	ipath "github.com/ipfs/boxo/path"
+	icore "github.com/ipfs/kubo/core/coreiface"
+)
+
+type VersionUsage struct {
+	*Version
+	// Logical is the total size of the files in the version.
+	Logical uint64
+	// Stored is the size of the blocks which make up the version, counting
+	// blocks shared between its files only once.
+	Stored uint64
+	// Unique is the size of the blocks which none of the version's parents
+	// contain, i.e. what the version added to the blockstore.
+	Unique uint64
+	Blocks int
+	// Incomplete is set when some of the version's blocks are not available
+	// locally, in which case the sizes only count those which are.
+	Incomplete bool
+}
+
+type DiskUsage struct {
+	Versions []VersionUsage
+	// Logical is the sum of the logical sizes of every version in the
+	// history, and Stored the size of the blocks they need between them.
+	Logical    uint64
+	Stored     uint64
+	Blocks     int
+	Incomplete int
+}
+
+// DedupRatio is how many times larger the history would be if versions did
+// not share blocks.
+func (u DiskUsage) DedupRatio() float64 {
+	if u.Stored == 0 {
+		return 1
+	}
+	return float64(u.Logical) / float64(u.Stored)
+}
+
+type blockLink struct {
+	cid cid.Cid
+	// tsize is the size of the linked block and everything below it, as
+	// recorded in the link, or zero if it is not known.
+	tsize uint64
+}
+
+type blockInfo struct {
+	size  uint64
+	links []blockLink
+}
+
+type blockGraph struct {
+	api    icore.CoreAPI
+	blocks map[cid.Cid]blockInfo
+}
+
+// block returns the size and links of a block, or false if it is not
+// available. A block is only decoded when it may have links: raw blocks never
+// do, and neither does a block whose size is the whole of the size recorded
+// in the link to it. Leaves, which hold the data, are therefore only stat'ed.
+func (g *blockGraph) block(ctx context.Context, link blockLink) (blockInfo, bool, error) {
+	if info, ok := g.blocks[link.cid]; ok {
+		return info, true, nil
+	}
+
+	stat, err := g.api.Block().Stat(ctx, ipath.FromCid(link.cid))
+	if ctx.Err() != nil {
+		return blockInfo{}, false, ctx.Err()
+	} else if err != nil {
+		return blockInfo{}, false, nil
+	}
+
+	info := blockInfo{size: uint64(stat.Size())}
+	if link.cid.Type() != cid.Raw && link.tsize != info.size {
+		node, err := g.api.Dag().Get(ctx, link.cid)
+		if ctx.Err() != nil {
+			return blockInfo{}, false, ctx.Err()
+		} else if err != nil {
+			return blockInfo{}, false, nil
+		}
+		for _, l := range node.Links() {
+			info.links = append(info.links, blockLink{cid: l.Cid, tsize: l.Size})
+		}
+	}
+
+	g.blocks[link.cid] = info
+	return info, true, nil
+}
+
+// reachable returns the locally available blocks below root and whether any
+// were missing.
+func (g *blockGraph) reachable(ctx context.Context, root cid.Cid) (map[cid.Cid]bool, bool, error) {
+	reached := make(map[cid.Cid]bool)
+	complete := true
+	queue := []blockLink{{cid: root}}
+	for len(queue) != 0 {
+		link := queue[0]
+		queue = queue[1:]
+		if reached[link.cid] {
+			continue
+		}
+
+		info, ok, err := g.block(ctx, link)
+		if err != nil {
+			return nil, false, err
+		} else if !ok {
+			complete = false
+			continue
+		}
+
+		reached[link.cid] = true
+		queue = append(queue, info.links...)
+	}
+	return reached, complete, nil
+}
+
+// DiskUsage reports the storage used by the versions given by revs, or every
+// version if there are none, along with that of the whole history. Only the
+// local blockstore is consulted.
+//
+// Each version's blocks are compared with those of its parents, so the block
+// sets of a version are only kept until all of its children have been
+// measured.
+func (d *Dorothy) DiskUsage(revs ...string) (*DiskUsage, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	} else if d.Manifest == nil {
+		return nil, fmt.Errorf("no manifest found")
+	}
+
+	offline, err := d.Ipfs.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		return nil, err
+	}
+	local := &Ipfs{CoreAPI: offline}
+	graph := &blockGraph{api: offline, blocks: make(map[cid.Cid]blockInfo)}
+
+	known := make(map[string]bool)
+	pending := make(map[string]int)
+	for _, version := range d.Manifest.Versions {
+		known[version.Hash] = true
+	}
+	for _, version := range d.Manifest.Versions {
+		for _, parent := range version.Parents {
+			if known[parent] {
+				pending[parent]++
+			}
+		}
+	}
+
+	sets := make(map[string]map[cid.Cid]bool)
+	complete := make(map[string]bool)
+	blocksOf := func(hash string) (map[cid.Cid]bool, error) {
+		if blocks, ok := sets[hash]; ok {
+			return blocks, nil
+		}
+		root, err := cid.Decode(hash)
+		if err != nil {
+			return nil, err
+		}
+		blocks, ok, err := graph.reachable(d, root)
+		if err != nil {
+			return nil, err
+		}
+		sets[hash] = blocks
+		complete[hash] = ok
+		return blocks, nil
+	}
+
+	usage := &DiskUsage{}
+	measured := make(map[string]VersionUsage)
+	for _, version := range d.Manifest.Versions {
+		if _, ok := measured[version.Hash]; ok {
+			continue
+		}
+
+		blocks, err := blocksOf(version.Hash)
+		if err != nil {
+			return nil, err
+		}
+		var parents []map[cid.Cid]bool
+		for _, parent := range version.Parents {
+			if known[parent] {
+				set, err := blocksOf(parent)
+				if err != nil {
+					return nil, err
+				}
+				parents = append(parents, set)
+			}
+		}
+
+		u := VersionUsage{
+			Version:    version,
+			Blocks:     len(blocks),
+			Incomplete: !complete[version.Hash],
+		}
+		for c := range blocks {
+			size := graph.blocks[c].size
+			u.Stored += size
+
+			inParent := false
+			for _, set := range parents {
+				if set[c] {
+					inParent = true
+					break
+				}
+			}
+			if !inParent {
+				u.Unique += size
+			}
+		}
+
+		if u.Incomplete {
+			usage.Incomplete++
+		}
+		if !u.Incomplete || version.Summary != nil {
+			summary, err := local.VersionSummary(d, version)
+			if err != nil {
+				return nil, fmt.Errorf("version %s: %v", version.Hash, err)
+			}
+			u.Logical = summary.Bytes
+			usage.Logical += summary.Bytes
+		}
+		measured[version.Hash] = u
+
+		for _, parent := range version.Parents {
+			if !known[parent] {
+				continue
+			}
+			if pending[parent]--; pending[parent] == 0 {
+				if _, done := measured[parent]; done {
+					delete(sets, parent)
+				}
+			}
+		}
+		if pending[version.Hash] == 0 {
+			delete(sets, version.Hash)
+		}
+	}
+
+	for _, info := range graph.blocks {
+		usage.Stored += info.size
+	}
+	usage.Blocks = len(graph.blocks)
+
+	versions := d.Manifest.ReverseVersions()
+	if len(revs) != 0 {
+		versions = nil
+		for _, rev := range revs {
+			version, err := d.findVersion(rev)
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, version)
+		}
+	}
+
+	for _, version := range versions {
+		usage.Versions = append(usage.Versions, measured[version.Hash])
+	}
+
+	return usage, nil
+}
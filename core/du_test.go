@@ -0,0 +1,72 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskUsage(t *testing.T) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	large := strings.Repeat("1,2,3\n", 100000)
+	if err := os.WriteFile(filepath.Join(dir, "large.csv"), []byte(large), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := client.Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "small.csv"), []byte("4,5,6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := client.SaveManifest(ctx, &Manifest{Versions: []*Version{
+		{Date: time.Now(), Hash: first, PathType: PathTypeDirectory},
+		{Date: time.Now().Add(time.Minute), Hash: second, PathType: PathTypeDirectory, Parents: []string{first}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: manifest}
+
+	usage, err := d.DiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage.Versions) != 2 || usage.Versions[0].Hash != second {
+		t.Fatalf("expected both versions, newest first, got %+v", usage.Versions)
+	}
+
+	child, parent := usage.Versions[0], usage.Versions[1]
+	if parent.Logical != uint64(len(large)) || child.Logical != uint64(len(large)+6) {
+		t.Errorf("unexpected logical sizes %d and %d", parent.Logical, child.Logical)
+	}
+	if parent.Unique != parent.Stored {
+		t.Errorf("expected everything in the first version to be unique, got %d of %d", parent.Unique, parent.Stored)
+	}
+	if child.Unique == 0 || child.Unique > 1024 {
+		t.Errorf("expected the second version to add only a directory and a small file, got %d bytes", child.Unique)
+	}
+	if usage.Stored != parent.Stored+child.Unique {
+		t.Errorf("expected shared blocks to be counted once, got %d", usage.Stored)
+	}
+	if usage.DedupRatio() < 1.9 {
+		t.Errorf("expected the history to deduplicate, got a ratio of %.2f", usage.DedupRatio())
+	}
+
+	usage, err = d.DiskUsage(first[:10])
+	if err != nil {
+		t.Fatal(err)
+	} else if len(usage.Versions) != 1 || usage.Versions[0].Hash != first {
+		t.Errorf("expected only the requested version, got %+v", usage.Versions)
+	}
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ipfs/boxo/files"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	"github.com/klauspost/compress/zstd"
+
+	ipath "github.com/ipfs/boxo/path"
+)
+
+type ArchiveFormat string
+
+const (
+	ArchiveFormatCar    ArchiveFormat = "car"
+	ArchiveFormatTar    ArchiveFormat = "tar"
+	ArchiveFormatZip    ArchiveFormat = "zip"
+	ArchiveFormatTarZst ArchiveFormat = "tar.zst"
+)
+
+var AllArchiveFormat = []ArchiveFormat{
+	ArchiveFormatCar,
+	ArchiveFormatTar,
+	ArchiveFormatZip,
+	ArchiveFormatTarZst,
+}
+
+func (f ArchiveFormat) IsValid() bool {
+	switch f {
+	case ArchiveFormatCar, ArchiveFormatTar, ArchiveFormatZip, ArchiveFormatTarZst:
+		return true
+	}
+	return false
+}
+
+func (f ArchiveFormat) String() string {
+	return string(f)
+}
+
+func (f ArchiveFormat) Extension() string {
+	return "." + string(f)
+}
+
+func (f ArchiveFormat) ContentType() string {
+	switch f {
+	case ArchiveFormatCar:
+		return "application/vnd.ipld.car"
+	case ArchiveFormatTar:
+		return "application/x-tar"
+	case ArchiveFormatZip:
+		return "application/zip"
+	case ArchiveFormatTarZst:
+		return "application/zstd"
+	}
+	return "application/octet-stream"
+}
+
+// ArchiveFormatOf guesses the format of an archive from its filename.
+func ArchiveFormatOf(filename string) (ArchiveFormat, bool) {
+	// tar.zst must be checked before tar's shorter suffix could match.
+	for _, format := range []ArchiveFormat{ArchiveFormatTarZst, ArchiveFormatCar, ArchiveFormatTar, ArchiveFormatZip} {
+		if strings.HasSuffix(filename, format.Extension()) {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// Export writes the content at subpath of a version to w. A CAR archive holds
+// every block of the content, so that importing it elsewhere reproduces the
+// same CIDs; the other formats hold the files under a directory named name.
+func (s *Ipfs) Export(ctx context.Context, hash, subpath, name string, format ArchiveFormat, w io.Writer) error {
+	p, err := versionPath(hash, subpath)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case ArchiveFormatCar:
+		resolved, _, err := s.ResolvePath(ctx, p)
+		if err != nil {
+			return err
+		}
+		return car.WriteCar(ctx, s.Dag(), []cid.Cid{resolved.RootCid()}, w)
+	case ArchiveFormatTar:
+		return s.exportTar(ctx, p, name, w)
+	case ArchiveFormatTarZst:
+		encoder, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := s.exportTar(ctx, p, name, encoder); err != nil {
+			encoder.Close()
+			return err
+		}
+		return encoder.Close()
+	case ArchiveFormatZip:
+		return s.exportZip(ctx, p, name, w)
+	}
+	return fmt.Errorf("unsupported archive format %q", format)
+}
+
+func (s *Ipfs) exportTar(ctx context.Context, p ipath.Path, name string, w io.Writer) error {
+	node, err := s.Unixfs().Get(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	writer, err := files.NewTarWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteFile(node, name); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func (s *Ipfs) exportZip(ctx context.Context, p ipath.Path, name string, w io.Writer) error {
+	node, err := s.Unixfs().Get(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	writer := zip.NewWriter(w)
+	err = files.Walk(node, func(fpath string, node files.Node) error {
+		fpath = path.Join(name, fpath)
+		switch node := node.(type) {
+		case files.Directory:
+			_, err := writer.Create(fpath + "/")
+			return err
+		case *files.Symlink:
+			header := &zip.FileHeader{Name: fpath, Method: zip.Store}
+			header.SetMode(os.ModeSymlink | 0777)
+			entry, err := writer.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(entry, node.Target)
+			return err
+		case files.File:
+			entry, err := writer.Create(fpath)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(entry, node)
+			return err
+		}
+		return fmt.Errorf("%q: unsupported node type", fpath)
+	})
+	if err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// Export writes the content of a version, given as rev[:subpath], to w.
+func (d *Dorothy) Export(spec string, format ArchiveFormat, w io.Writer) error {
+	if !d.Ipfs.IsConnected() {
+		return fmt.Errorf("not connected to IPFS")
+	} else if !format.IsValid() {
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+
+	version, subpath, err := d.ResolveRevision(spec)
+	if err != nil {
+		return err
+	}
+
+	name := version.Hash
+	if subpath = strings.Trim(path.Clean("/"+subpath), "/"); subpath != "" {
+		name = path.Base(subpath)
+	}
+
+	return d.Ipfs.Export(d, version.Hash, subpath, name, format, w)
+}
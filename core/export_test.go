@@ -0,0 +1,172 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ipld/go-car"
+	"github.com/klauspost/compress/zstd"
+)
+
+func exportTestVersion(t *testing.T) (*Ipfs, *Dorothy, string) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.txt": "a", "sub/b.txt": "bb"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := client.Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: &Manifest{
+		Versions: []*Version{{Hash: hash, PathType: PathTypeDirectory}},
+	}}
+	return client, d, hash
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	entries := make(map[string]string)
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[header.Name] = string(content)
+	}
+}
+
+func TestExport(t *testing.T) {
+	_, d, hash := exportTestVersion(t)
+
+	expected := map[string]string{
+		hash:                "",
+		hash + "/a.txt":     "a",
+		hash + "/sub":       "",
+		hash + "/sub/b.txt": "bb",
+	}
+	check := func(format ArchiveFormat, entries map[string]string) {
+		if len(entries) != len(expected) {
+			t.Errorf("%s: expected %v, got %v", format, expected, entries)
+		}
+		for name, content := range expected {
+			if got, ok := entries[name]; !ok || got != content {
+				t.Errorf("%s: expected %q to contain %q, got %q", format, name, content, got)
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := d.Export(hash[:10], ArchiveFormatTar, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	check(ArchiveFormatTar, readTar(t, &buffer))
+
+	buffer.Reset()
+	if err := d.Export(hash, ArchiveFormatTarZst, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := zstd.NewReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(ArchiveFormatTarZst, readTar(t, decoder))
+	decoder.Close()
+
+	buffer.Reset()
+	if err := d.Export(hash, ArchiveFormatZip, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string]string)
+	for _, file := range reader.File {
+		handle, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(handle)
+		handle.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[filepath.Clean(file.Name)] = string(content)
+	}
+	check(ArchiveFormatZip, entries)
+
+	buffer.Reset()
+	if err := d.Export(hash+":sub", ArchiveFormatTar, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range readTar(t, &buffer) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "sub" || names[1] != "sub/b.txt" {
+		t.Errorf("expected only the subdirectory to be exported, got %v", names)
+	}
+}
+
+func TestExportCar(t *testing.T) {
+	client, d, hash := exportTestVersion(t)
+
+	var buffer bytes.Buffer
+	if err := d.Export(hash, ArchiveFormatCar, &buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := car.NewCarReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.Header.Roots) != 1 || reader.Header.Roots[0].String() != hash {
+		t.Errorf("expected the version to be the root, got %v", reader.Header.Roots)
+	}
+
+	var blocks int
+	for {
+		block, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		blocks++
+
+		stored, err := client.Dag().Get(d, block.Cid())
+		if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(stored.RawData(), block.RawData()) {
+			t.Errorf("block %s does not match the blockstore", block.Cid())
+		}
+	}
+
+	missing, found, err := missingBlocks(d, client.CoreAPI, reader.Header.Roots[0])
+	if err != nil {
+		t.Fatal(err)
+	} else if len(missing) != 0 || found != blocks {
+		t.Errorf("expected all %d blocks in the archive, got %d", found, blocks)
+	}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/boxo/filestore"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/client/rpc"
+	"github.com/ipfs/kubo/repo"
+)
+
+// FilestoreEntry describes a block which references data in a file outside
+// of the blockstore, as created by a commit with --nocopy. Path is relative to
+// the directory containing the IPFS repository.
+type FilestoreEntry struct {
+	Cid    string `json:"cid"`
+	Status string `json:"status"`
+	Path   string `json:"path"`
+	Offset uint64 `json:"offset"`
+	Size   uint64 `json:"size"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (e FilestoreEntry) IsOk() bool {
+	return e.Status == filestore.StatusOk.String()
+}
+
+func newFilestoreEntry(r *filestore.ListRes) FilestoreEntry {
+	entry := FilestoreEntry{
+		Status: r.Status.String(),
+		Path:   r.FilePath,
+		Offset: r.Offset,
+		Size:   r.Size,
+		Error:  r.ErrorMsg,
+	}
+	if r.Key.Defined() {
+		entry.Cid = r.Key.String()
+	}
+	return entry
+}
+
+// enableFilestore turns on the filestore for repositories created before
+// dorothy supported --nocopy. It reports whether the configuration changed, in
+// which case the repository must be reopened for the change to take effect.
+func enableFilestore(r repo.Repo) (bool, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return false, err
+	}
+	if cfg.Experimental.FilestoreEnabled {
+		return false, nil
+	}
+	return true, r.SetConfigKey("Experimental.FilestoreEnabled", true)
+}
+
+// VerifyFilestore checks that every block stored by reference still matches
+// the contents of the file it points to.
+func (s *Ipfs) VerifyFilestore(ctx context.Context) ([]FilestoreEntry, error) {
+	if api, ok := s.CoreAPI.(*rpc.HttpApi); ok {
+		return verifyRemoteFilestore(ctx, api)
+	}
+
+	if s.node == nil || s.node.Filestore == nil {
+		return nil, fmt.Errorf("filestore is not enabled")
+	}
+
+	next, err := filestore.VerifyAll(ctx, s.node.Filestore, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []FilestoreEntry
+	for r := next(ctx); r != nil; r = next(ctx) {
+		entries = append(entries, newFilestoreEntry(r))
+	}
+	return entries, ctx.Err()
+}
+
+func verifyRemoteFilestore(ctx context.Context, api *rpc.HttpApi) ([]FilestoreEntry, error) {
+	res, err := api.Request("filestore/verify").Option("file-order", true).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	var entries []FilestoreEntry
+	decoder := json.NewDecoder(res.Output)
+	for {
+		var r struct {
+			Status   filestore.Status
+			ErrorMsg string
+			Key      map[string]string
+			FilePath string
+			Offset   uint64
+			Size     uint64
+		}
+		if err := decoder.Decode(&r); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		key, _ := cid.Parse(r.Key["/"])
+		entries = append(entries, newFilestoreEntry(&filestore.ListRes{
+			Status:   r.Status,
+			ErrorMsg: r.ErrorMsg,
+			Key:      key,
+			FilePath: r.FilePath,
+			Offset:   r.Offset,
+			Size:     r.Size,
+		}))
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/kubo/core/coreiface/options"
+)
+
+func TestVerifyFilestore(t *testing.T) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(filename, []byte(strings.Repeat("1,2,3\n", 100000)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := client.Add(ctx, dir, options.Unixfs.Nocopy(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := client.VerifyFilestore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) < 2 {
+		t.Fatalf("expected the file to be split into several referenced blocks, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if !entry.IsOk() || !strings.HasSuffix(filename, entry.Path) {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	}
+
+	dest := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dest, "data.csv"), []byte(strings.Repeat("1,2,3\n", 100000)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Checkout(ctx, hash, "", dest, NewCheckoutSettings()); err != nil {
+		t.Errorf("expected an identical local copy to be recognized, got %v", err)
+	}
+
+	if err := os.WriteFile(filename, []byte(strings.Repeat("4,5,6\n", 100000)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err = client.VerifyFilestore(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changed int
+	for _, entry := range entries {
+		if !entry.IsOk() {
+			changed++
+		}
+	}
+	if changed != len(entries) {
+		t.Errorf("expected all %d blocks to fail verification, got %d", len(entries), changed)
+	}
+}
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/core/coreiface/options"
+
+	ipath "github.com/ipfs/boxo/path"
+	icore "github.com/ipfs/kubo/core/coreiface"
+)
+
+type FsckProblemKind string
+
+const (
+	FsckBadPointer     FsckProblemKind = "bad-pointer"
+	FsckBadManifest    FsckProblemKind = "bad-manifest"
+	FsckInvalidVersion FsckProblemKind = "invalid-version"
+	FsckDuplicate      FsckProblemKind = "duplicate"
+	FsckMissingParent  FsckProblemKind = "missing-parent"
+	FsckCycle          FsckProblemKind = "cycle"
+	FsckMissingBlocks  FsckProblemKind = "missing-blocks"
+)
+
+type FsckProblem struct {
+	Kind FsckProblemKind
+	// Hash is the version, or for pointer and manifest problems the
+	// manifest, that the problem concerns.
+	Hash   string
+	Detail string
+	// Missing lists the blocks of the version which are not in the local
+	// blockstore. Blocks below a missing block cannot be inspected, so more
+	// may be missing than are listed.
+	Missing []string
+}
+
+func (p FsckProblem) String() string {
+	return fmt.Sprintf("%s %s: %s", p.Kind, p.Hash, p.Detail)
+}
+
+type FsckReport struct {
+	Manifest string
+	Versions int
+	Blocks   int
+	Problems []FsckProblem
+	// Repaired lists the manifest and versions whose missing blocks were
+	// fetched from the network.
+	Repaired []string
+}
+
+func (r *FsckReport) add(kind FsckProblemKind, hash, format string, args ...any) {
+	r.Problems = append(r.Problems, FsckProblem{
+		Kind:   kind,
+		Hash:   hash,
+		Detail: fmt.Sprintf(format, args...),
+	})
+}
+
+// Fsck checks the integrity of the repository: that the manifest pointer
+// resolves to a valid manifest, that the version graph is sound, and that the
+// content of every version is present in the local blockstore. With repair,
+// anything missing is fetched from the network.
+func (d *Dorothy) Fsck(repair bool) (*FsckReport, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	offline, err := d.Ipfs.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		return nil, err
+	}
+	local := &Ipfs{CoreAPI: offline}
+
+	report := &FsckReport{}
+
+	pointer, err := os.ReadFile(d.ManifestPath())
+	if err != nil {
+		return nil, err
+	}
+	report.Manifest = strings.TrimSpace(string(pointer))
+
+	root, err := cid.Decode(report.Manifest)
+	if err != nil {
+		report.add(FsckBadPointer, report.Manifest, "manifest pointer is not a valid hash: %v", err)
+		return report, nil
+	}
+
+	missing, _, err := d.checkBlocks(report, offline, root, repair)
+	if err != nil {
+		return nil, err
+	} else if len(missing) != 0 {
+		report.Problems = append(report.Problems, FsckProblem{
+			Kind:    FsckBadPointer,
+			Hash:    report.Manifest,
+			Detail:  fmt.Sprintf("manifest cannot be retrieved: %d block(s) missing", len(missing)),
+			Missing: missing,
+		})
+		return report, nil
+	}
+
+	manifest, err := local.GetManifest(d, report.Manifest)
+	if err != nil {
+		report.add(FsckBadManifest, report.Manifest, "cannot parse manifest: %v", err)
+		return report, nil
+	}
+	report.Versions = len(manifest.Versions)
+
+	checkVersionGraph(report, manifest)
+
+	for _, version := range manifest.Versions {
+		root, err := cid.Decode(version.Hash)
+		if err != nil {
+			continue
+		}
+
+		missing, blocks, err := d.checkBlocks(report, offline, root, repair)
+		if err != nil {
+			return nil, err
+		}
+		report.Blocks += blocks
+
+		if len(missing) != 0 {
+			report.Problems = append(report.Problems, FsckProblem{
+				Kind:    FsckMissingBlocks,
+				Hash:    version.Hash,
+				Detail:  fmt.Sprintf("%d block(s) missing from the local blockstore", len(missing)),
+				Missing: missing,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// checkBlocks returns the blocks below root which are missing locally. With
+// repair, it first pins root, which fetches whatever is missing from the
+// network, and records whether that succeeded.
+func (d *Dorothy) checkBlocks(report *FsckReport, offline icore.CoreAPI, root cid.Cid, repair bool) ([]string, int, error) {
+	missing, blocks, err := missingBlocks(d, offline, root)
+	if err != nil || len(missing) == 0 || !repair {
+		return missing, blocks, err
+	}
+
+	if err := d.Ipfs.Pin().Add(d, ipath.FromCid(root)); err != nil && d.Err() != nil {
+		return nil, 0, d.Err()
+	}
+	missing, blocks, err = missingBlocks(d, offline, root)
+	if err == nil && len(missing) == 0 {
+		report.Repaired = append(report.Repaired, root.String())
+	}
+	return missing, blocks, err
+}
+
+func checkVersionGraph(report *FsckReport, manifest *Manifest) {
+	seen := make(map[string]int)
+	for _, version := range manifest.Versions {
+		seen[version.Hash]++
+	}
+
+	for _, version := range manifest.Versions {
+		if count := seen[version.Hash]; count > 1 {
+			report.add(FsckDuplicate, version.Hash, "version appears %d times in the manifest", count)
+			seen[version.Hash] = -count
+		}
+		if _, err := cid.Decode(version.Hash); err != nil {
+			report.add(FsckInvalidVersion, version.Hash, "invalid hash: %v", err)
+		}
+		if !version.PathType.IsValid() {
+			report.add(FsckInvalidVersion, version.Hash, "invalid path type %q", version.PathType)
+		}
+		if version.Date.IsZero() {
+			report.add(FsckInvalidVersion, version.Hash, "version has no date")
+		}
+		for _, parent := range version.Parents {
+			if _, ok := seen[parent]; !ok {
+				report.add(FsckMissingParent, version.Hash, "parent %s is not in the manifest", parent)
+			}
+		}
+	}
+
+	for _, cycle := range findCycles(manifest) {
+		report.add(FsckCycle, cycle[0], "versions are their own ancestors: %s", strings.Join(cycle, " -> "))
+	}
+}
+
+// findCycles returns each cycle in the parent graph of the manifest as the
+// list of hashes along it.
+func findCycles(manifest *Manifest) [][]string {
+	parents := make(map[string][]string)
+	for _, version := range manifest.Versions {
+		parents[version.Hash] = append(parents[version.Hash], version.Parents...)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+
+	var cycles [][]string
+	var stack []string
+	var visit func(string)
+	visit = func(hash string) {
+		state[hash] = visiting
+		stack = append(stack, hash)
+		for _, parent := range parents[hash] {
+			switch state[parent] {
+			case unvisited:
+				if _, ok := parents[parent]; ok {
+					visit(parent)
+				}
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == parent {
+						cycle := append([]string{}, stack[i:]...)
+						cycles = append(cycles, append(cycle, parent))
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[hash] = visited
+	}
+
+	for _, version := range manifest.Versions {
+		if state[version.Hash] == unvisited {
+			visit(version.Hash)
+		}
+	}
+	return cycles
+}
+
+// missingBlocks walks the DAG below root and returns the blocks which api
+// cannot retrieve along with the number of blocks which it could. It is given
+// an offline API, so that it only inspects the local blockstore.
+func missingBlocks(ctx context.Context, api icore.CoreAPI, root cid.Cid) ([]string, int, error) {
+	var missing []string
+	var found int
+
+	seen := make(map[cid.Cid]bool)
+	queue := []cid.Cid{root}
+	for len(queue) != 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		node, err := api.Dag().Get(ctx, c)
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		} else if err != nil {
+			missing = append(missing, c.String())
+			continue
+		}
+
+		found++
+		for _, link := range node.Links() {
+			queue = append(queue, link.Cid)
+		}
+	}
+
+	return missing, found, nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/boxo/path"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/core/coreiface/options"
+)
+
+func TestFsck(t *testing.T) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.csv", "b.csv"} {
+		content := strings.Repeat(name+"\n", 100000)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := client.Add(ctx, dir, options.Unixfs.Pin(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Now()
+	manifest, err := client.SaveManifest(ctx, &Manifest{Versions: []*Version{
+		{Author: "X <x@y>", Date: date, Hash: hash, PathType: PathTypeDirectory},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: manifest}
+	if err := d.WriteManifestFile(ReflogInit, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := d.Fsck(false)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(report.Problems) != 0 {
+		t.Fatalf("expected no problems, got %v", report.Problems)
+	} else if report.Versions != 1 || report.Blocks < 3 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	p, err := path.NewPath("/ipfs/" + hash + "/b.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, _, err := client.ResolvePath(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := client.Dag().Get(ctx, resolved.RootCid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lost := node.Links()[0].Cid
+	if err := client.Block().Rm(ctx, path.FromCid(lost)); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = d.Fsck(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Kind != FsckMissingBlocks {
+		t.Fatalf("expected missing blocks, got %v", report.Problems)
+	} else if missing := report.Problems[0].Missing; len(missing) != 1 || missing[0] != lost.String() {
+		t.Errorf("expected %s to be missing, got %v", lost, missing)
+	}
+
+	// An offline node cannot fetch the block, so the repair fails quickly
+	// rather than waiting on the network.
+	report, err = d.Fsck(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Repaired) != 0 || len(report.Problems) != 1 {
+		t.Errorf("expected the repair to fail, got %+v", report)
+	}
+}
+
+func TestFsckVersionGraph(t *testing.T) {
+	hash := func(s string) string {
+		c, err := cid.V0Builder{}.Sum([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.String()
+	}
+	a, b, c := hash("a"), hash("b"), hash("c")
+
+	manifest := &Manifest{Versions: []*Version{
+		{Date: time.Now(), Hash: a, PathType: PathTypeFile, Parents: []string{c}},
+		{Date: time.Now(), Hash: b, PathType: PathTypeFile, Parents: []string{a, hash("missing")}},
+		{Date: time.Now(), Hash: c, PathType: PathTypeFile, Parents: []string{b}},
+		{Date: time.Now(), Hash: c, PathType: "LINK"},
+	}}
+
+	report := &FsckReport{}
+	checkVersionGraph(report, manifest)
+
+	kinds := make(map[FsckProblemKind]int)
+	for _, problem := range report.Problems {
+		kinds[problem.Kind]++
+	}
+	expected := map[FsckProblemKind]int{
+		FsckDuplicate:      1,
+		FsckInvalidVersion: 1,
+		FsckMissingParent:  1,
+		FsckCycle:          1,
+	}
+	for kind, count := range expected {
+		if kinds[kind] != count {
+			t.Errorf("expected %d %s problem(s), got %d: %v", count, kind, kinds[kind], report.Problems)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"strings"
+)
+
+type GraphEntry struct {
+	Version *Version
+	Before  []string
+	Node    string
+	After   []string
+	Padding string
+}
+
+type graphEdge struct {
+	from int
+	to   int
+}
+
+func indexOfHash(lanes []string, hash string) int {
+	for i, h := range lanes {
+		if h == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+func renderLanes(lanes []string, node int) string {
+	row := make([]byte, 0, 2*len(lanes))
+	for i := range lanes {
+		if i == node {
+			row = append(row, '*', ' ')
+		} else {
+			row = append(row, '|', ' ')
+		}
+	}
+	return strings.TrimRight(string(row), " ")
+}
+
+func renderEdges(edges []graphEdge, width int) []string {
+	pos := make([]int, len(edges))
+	for i, edge := range edges {
+		pos[i] = edge.from
+	}
+
+	var rows []string
+	for {
+		done := true
+		for i, edge := range edges {
+			if pos[i] != edge.to {
+				done = false
+				break
+			}
+		}
+		if done {
+			break
+		}
+
+		row := []byte(strings.Repeat(" ", 2*width+1))
+		set := func(i int, c byte) {
+			if row[i] != ' ' && row[i] != c {
+				c = 'X'
+			}
+			row[i] = c
+		}
+		for i, edge := range edges {
+			p := pos[i]
+			switch {
+			case p == edge.to:
+				set(2*p, '|')
+			case edge.to < p:
+				set(2*p-1, '/')
+				pos[i]--
+			default:
+				set(2*p+1, '\\')
+				pos[i]++
+			}
+		}
+		rows = append(rows, strings.TrimRight(string(row), " "))
+	}
+
+	return rows
+}
+
+// Graph lays out the manifest's versions, newest first, as an ASCII rendering
+// of the version DAG in the style of `git log --graph`. Parents which are not
+// in the manifest are not drawn.
+func (m *Manifest) Graph() []GraphEntry {
+	known := make(map[string]bool)
+	for _, version := range m.Versions {
+		known[version.Hash] = true
+	}
+
+	var lanes []string
+	entries := make([]GraphEntry, 0, len(m.Versions))
+	for i := len(m.Versions) - 1; i >= 0; i-- {
+		version := m.Versions[i]
+		entry := GraphEntry{Version: version}
+
+		idx := indexOfHash(lanes, version.Hash)
+		if idx < 0 {
+			lanes = append(lanes, version.Hash)
+			idx = len(lanes) - 1
+		}
+
+		var edges []graphEdge
+		var next []string
+		converge := false
+		for k, hash := range lanes {
+			if hash == version.Hash && k != idx {
+				edges = append(edges, graphEdge{from: k, to: idx})
+				converge = true
+			} else {
+				edges = append(edges, graphEdge{from: k, to: len(next)})
+				next = append(next, hash)
+			}
+		}
+		if converge {
+			entry.Before = renderEdges(edges, len(lanes))
+			lanes = next
+		}
+
+		entry.Node = renderLanes(lanes, idx)
+
+		var parents []string
+		for _, parent := range version.Parents {
+			if known[parent] && indexOfHash(parents, parent) < 0 {
+				parents = append(parents, parent)
+			}
+		}
+
+		next = nil
+		for k, hash := range lanes {
+			if k == idx {
+				for _, parent := range parents {
+					if indexOfHash(next, parent) < 0 && indexOfHash(lanes[k+1:], parent) < 0 {
+						next = append(next, parent)
+					}
+				}
+			} else if indexOfHash(next, hash) < 0 {
+				next = append(next, hash)
+			}
+		}
+
+		edges = nil
+		for k, hash := range lanes {
+			if k == idx {
+				for _, parent := range parents {
+					edges = append(edges, graphEdge{from: k, to: indexOfHash(next, parent)})
+				}
+			} else {
+				edges = append(edges, graphEdge{from: k, to: indexOfHash(next, hash)})
+			}
+		}
+
+		width := len(lanes)
+		if len(next) > width {
+			width = len(next)
+		}
+		entry.After = renderEdges(edges, width)
+		entry.Padding = renderLanes(next, -1)
+
+		lanes = next
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
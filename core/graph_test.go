@@ -0,0 +1,114 @@
+package core
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func graphManifest(t *testing.T, specs ...[]string) *Manifest {
+	t.Helper()
+
+	date, _ := time.Parse("2006-01-02T15:04:05", "2023-03-16T10:00:00")
+	var versions []*Version
+	for i, spec := range specs {
+		versions = append(versions, &Version{
+			Author:   "Douglas G. Moore <doug@dglmoore.com>",
+			Date:     date.Add(time.Duration(i) * time.Hour),
+			Message:  spec[0],
+			Hash:     spec[0],
+			PathType: PathTypeFile,
+			Parents:  spec[1:],
+		})
+	}
+
+	sorted, err := toposort(versions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Manifest{Versions: sorted}
+}
+
+func renderGraph(entries []GraphEntry) []string {
+	var lines []string
+	for _, entry := range entries {
+		lines = append(lines, entry.Before...)
+		lines = append(lines, entry.Node+" "+entry.Version.Hash)
+		lines = append(lines, entry.After...)
+	}
+	return lines
+}
+
+func TestGraphEmpty(t *testing.T) {
+	manifest := &Manifest{}
+	if entries := manifest.Graph(); len(entries) != 0 {
+		t.Errorf("expected no entries, got %d", len(entries))
+	}
+}
+
+func TestGraphLinear(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"}, []string{"c", "b"})
+
+	got := renderGraph(manifest.Graph())
+	expected := []string{
+		"* c",
+		"* b",
+		"* a",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestGraphBranchAndMerge(t *testing.T) {
+	manifest := graphManifest(t,
+		[]string{"a"},
+		[]string{"b", "a"},
+		[]string{"c", "a"},
+		[]string{"d", "b", "c"},
+	)
+
+	got := renderGraph(manifest.Graph())
+	expected := []string{
+		"* d",
+		"|\\",
+		"| * c",
+		"* | b",
+		"|/",
+		"* a",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestGraphDisjointRoots(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b"})
+
+	entries := manifest.Graph()
+	got := renderGraph(entries)
+	expected := []string{
+		"* b",
+		"* a",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if entries[0].Padding != "" {
+		t.Errorf("expected empty padding after root, got %q", entries[0].Padding)
+	}
+}
+
+func TestGraphIgnoresUnknownParents(t *testing.T) {
+	manifest := graphManifest(t, []string{"a", "missing"}, []string{"b", "a"})
+
+	got := renderGraph(manifest.Graph())
+	expected := []string{
+		"* b",
+		"* a",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
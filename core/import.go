@@ -0,0 +1,212 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ipfs/boxo/files"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/core/coreiface/options"
+	"github.com/ipld/go-car"
+
+	ipath "github.com/ipfs/boxo/path"
+)
+
+type CarRoot struct {
+	Hash string
+	// Missing lists the blocks below the root which are neither in the
+	// archive nor already in the local blockstore.
+	Missing []string
+}
+
+func (r CarRoot) IsComplete() bool {
+	return len(r.Missing) == 0
+}
+
+type CarImport struct {
+	Roots  []CarRoot
+	Blocks int
+}
+
+// Root returns the imported root with the given hash, or the only root if
+// hash is empty.
+func (i *CarImport) Root(hash string) (CarRoot, error) {
+	if hash == "" {
+		if len(i.Roots) != 1 {
+			return CarRoot{}, fmt.Errorf("archive has %d roots; choose one", len(i.Roots))
+		}
+		return i.Roots[0], nil
+	}
+
+	for _, root := range i.Roots {
+		if root.Hash == hash {
+			return root, nil
+		}
+	}
+	return CarRoot{}, fmt.Errorf("%s is not a root of the archive", hash)
+}
+
+// isCompleteRoot reports whether hash is a CID whose blocks are all in the
+// local blockstore, as the roots of an archive imported without a commit are.
+func (d *Dorothy) isCompleteRoot(hash string) bool {
+	root, err := cid.Decode(hash)
+	if err != nil {
+		return false
+	}
+
+	offline, err := d.Ipfs.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		return false
+	}
+
+	missing, _, err := missingBlocks(d, offline, root)
+	return err == nil && len(missing) == 0
+}
+
+// putBlockWithPrefix stores a block under the CID prefix it had in the
+// archive rather than the default CIDv1 raw prefix.
+func putBlockWithPrefix(prefix cid.Prefix) options.BlockPutOption {
+	return func(settings *options.BlockPutSettings) error {
+		settings.CidPrefix = prefix
+		return nil
+	}
+}
+
+// ImportCar loads every block of a CAR archive into the blockstore and
+// returns the roots of the archive and the number of blocks it held.
+func (s *Ipfs) ImportCar(ctx context.Context, r io.Reader) ([]cid.Cid, int, error) {
+	reader, err := car.NewCarReader(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot read archive: %v", err)
+	}
+
+	var blocks int
+	for {
+		block, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, blocks, fmt.Errorf("cannot read archive: %v", err)
+		}
+
+		stat, err := s.Block().Put(ctx, bytes.NewReader(block.RawData()), putBlockWithPrefix(block.Cid().Prefix()))
+		if err != nil {
+			return nil, blocks, err
+		} else if !bytes.Equal(stat.Path().RootCid().Hash(), block.Cid().Hash()) {
+			return nil, blocks, fmt.Errorf("block %s does not match its content", block.Cid())
+		}
+		blocks++
+	}
+
+	return reader.Header.Roots, blocks, nil
+}
+
+// ImportCar loads a CAR archive into the blockstore and checks whether each of
+// its roots is complete locally. Complete roots are pinned unless nopin.
+func (d *Dorothy) ImportCar(r io.Reader, nopin bool) (*CarImport, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	roots, blocks, err := d.Ipfs.ImportCar(d, r)
+	if err != nil {
+		return nil, err
+	}
+
+	checked, err := d.checkRoots(roots, nopin)
+	if err != nil {
+		return nil, err
+	}
+	return &CarImport{Roots: checked, Blocks: blocks}, nil
+}
+
+func (d *Dorothy) checkRoots(roots []cid.Cid, nopin bool) ([]CarRoot, error) {
+	offline, err := d.Ipfs.WithOptions(options.Api.Offline(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var checked []CarRoot
+	for _, root := range roots {
+		missing, _, err := missingBlocks(d, offline, root)
+		if err != nil {
+			return nil, err
+		}
+		checked = append(checked, CarRoot{Hash: root.String(), Missing: missing})
+
+		if len(missing) == 0 && !nopin {
+			if err := d.Ipfs.Pin().Add(d, ipath.FromCid(root)); err != nil {
+				return nil, fmt.Errorf("cannot pin %s: %v", root, err)
+			}
+		}
+	}
+	return checked, nil
+}
+
+// CommitImported creates a version from the root of an imported archive.
+func (d *Dorothy) CommitImported(root CarRoot, message string, parents []string) ([]Conflict, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	if d.Config.User == nil || d.Config.User.Name == "" || d.Config.User.Email == "" {
+		return nil, fmt.Errorf("user not configured; see `dorothy config user`")
+	}
+
+	if message == "" {
+		return nil, fmt.Errorf("empty message; aborting")
+	}
+
+	if !root.IsComplete() {
+		return nil, fmt.Errorf("cannot commit %s: %d block(s) missing", root.Hash, len(root.Missing))
+	}
+
+	id, err := cid.Decode(root.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := d.Ipfs.Unixfs().Get(d, ipath.FromCid(id))
+	if err != nil {
+		return nil, fmt.Errorf("cannot commit %s: %v", root.Hash, err)
+	}
+	defer node.Close()
+
+	var pathtype PathType
+	switch node.(type) {
+	case files.Directory:
+		pathtype = PathTypeDirectory
+	case files.File:
+		pathtype = PathTypeFile
+	default:
+		return nil, fmt.Errorf("cannot commit %s: not a file or directory", root.Hash)
+	}
+
+	checksums, err := d.recordChecksums(root.Hash, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := d.recordSummary(root.Hash, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.MergeManifest(&Manifest{
+		Versions: []*Version{
+			{
+				Author:    d.Config.User.String(),
+				Date:      time.Now(),
+				Message:   message,
+				Hash:      root.Hash,
+				PathType:  pathtype,
+				Parents:   parents,
+				Checksums: checksums,
+				Summary:   summary,
+			},
+		},
+	}, ReflogImport, message)
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/ipld/go-car"
+	"github.com/ipld/go-car/util"
+)
+
+func TestImportCar(t *testing.T) {
+	_, source, hash := exportTestVersion(t)
+
+	var archive bytes.Buffer
+	if err := source.Export(hash, ArchiveFormatCar, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	client, ctx := setup(t)
+	empty, err := client.CreateEmptyManifest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: empty, Config: Config{
+		User: &UserConfig{Name: "X", Email: "x@y"},
+	}}
+	if err := d.WriteManifestFile(ReflogInit, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := d.ImportCar(bytes.NewReader(archive.Bytes()), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := imported.Root("")
+	if err != nil {
+		t.Fatal(err)
+	} else if root.Hash != hash || !root.IsComplete() || imported.Blocks == 0 {
+		t.Fatalf("expected a complete import of %s, got %+v", hash, imported)
+	}
+
+	if pinned, err := client.pinnedSet(ctx); err != nil {
+		t.Fatal(err)
+	} else if !pinned[hash] {
+		t.Errorf("expected the imported root to be pinned")
+	}
+
+	if file, err := d.Open(hash + ":sub/b.txt"); err != nil {
+		t.Errorf("expected an uncommitted root to be readable: %v", err)
+	} else {
+		content, _ := io.ReadAll(file)
+		file.Close()
+		if string(content) != "bb" {
+			t.Errorf("expected %q, got %q", "bb", content)
+		}
+	}
+	if _, err := d.Open("QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH"); err == nil {
+		t.Errorf("expected an unknown root to be rejected")
+	}
+
+	if _, err := d.CommitImported(root, "", nil); err == nil {
+		t.Errorf("expected an empty message to be rejected")
+	}
+	if conflicts, err := d.CommitImported(root, "imported", nil); err != nil || len(conflicts) != 0 {
+		t.Fatalf("unexpected result %v, %v", conflicts, err)
+	}
+	version, err := d.Manifest.FindVersion(hash)
+	if err != nil {
+		t.Fatal(err)
+	} else if version.PathType != PathTypeDirectory || version.Message != "imported" {
+		t.Errorf("unexpected version %+v", version)
+	} else if expected := (TreeSummary{Files: 2, Directories: 1, Bytes: 3}); version.Summary == nil || *version.Summary != expected {
+		t.Errorf("expected summary %v, got %v", expected, version.Summary)
+	}
+
+	if _, err := imported.Root("bafkqaaa"); err == nil {
+		t.Errorf("expected an unknown root to be rejected")
+	}
+}
+
+func TestImportIncompleteCar(t *testing.T) {
+	_, source, hash := exportTestVersion(t)
+
+	var archive bytes.Buffer
+	if err := source.Export(hash, ArchiveFormatCar, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the last block of the archive.
+	reader, err := car.NewCarReader(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var truncated bytes.Buffer
+	if err := car.WriteHeader(reader.Header, &truncated); err != nil {
+		t.Fatal(err)
+	}
+	var pending []byte
+	var pendingCid []byte
+	for {
+		block, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if pending != nil {
+			if err := util.LdWrite(&truncated, pendingCid, pending); err != nil {
+				t.Fatal(err)
+			}
+		}
+		pendingCid, pending = block.Cid().Bytes(), block.RawData()
+	}
+
+	client, ctx := setup(t)
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Config: Config{
+		User: &UserConfig{Name: "X", Email: "x@y"},
+	}}
+
+	imported, err := d.ImportCar(&truncated, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := imported.Root(hash)
+	if err != nil {
+		t.Fatal(err)
+	} else if root.IsComplete() || len(root.Missing) != 1 {
+		t.Errorf("expected one block to be missing, got %v", root.Missing)
+	}
+
+	if pinned, err := client.pinnedSet(ctx); err != nil {
+		t.Fatal(err)
+	} else if pinned[hash] {
+		t.Errorf("expected an incomplete root not to be pinned")
+	}
+
+	if _, err := d.CommitImported(root, "incomplete", nil); err == nil {
+		t.Errorf("expected an incomplete root not to be committed")
+	}
+}
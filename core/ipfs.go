@@ -11,6 +11,9 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
 
 	"github.com/ipfs/boxo/files"
 	"github.com/ipfs/boxo/path"
@@ -19,9 +22,11 @@ import (
 	"github.com/ipfs/kubo/core/coreiface/options"
 	"github.com/ipfs/kubo/core/node/libp2p"
 	"github.com/ipfs/kubo/plugin/loader"
+	"github.com/ipfs/kubo/repo"
 	"github.com/ipfs/kubo/repo/fsrepo"
 	"github.com/libp2p/go-libp2p/core/peer"
 
+	fslock "github.com/ipfs/go-fs-lock"
 	kuboconfig "github.com/ipfs/kubo/config"
 	kubo "github.com/ipfs/kubo/core"
 	icore "github.com/ipfs/kubo/core/coreiface"
@@ -29,6 +34,8 @@ import (
 
 var loadPluginsOnce sync.Once
 
+const lockPollInterval = 100 * time.Millisecond
+
 type Ipfs struct {
 	icore.CoreAPI
 	Identity peer.ID
@@ -55,11 +62,61 @@ func (s *Ipfs) IsConnected() bool {
 	return s != nil && s.CoreAPI != nil
 }
 
+// SharedIpfsPath is the location of the per-user IPFS repository which
+// datasets initialized with --shared use in place of their own.
+func SharedIpfsPath() string {
+	return filepath.Join(xdg.DataHome, "dorothy", "ipfs")
+}
+
+func (s *Ipfs) repoPath(dir string) string {
+	if s.config.Shared {
+		return SharedIpfsPath()
+	}
+	return dir
+}
+
 func (s *Ipfs) Initialize(dir string) error {
-	if !s.config.Global {
-		return s.initializeLocal(dir)
+	if s.config.Global {
+		return nil
+	} else if s.config.Shared {
+		return s.initializeShared()
+	}
+	return s.initializeLocal(dir)
+}
+
+func (s *Ipfs) initializeShared() error {
+	dir := SharedIpfsPath()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create shared IPFS repo: %v", err)
+	}
+
+	unlock, err := waitForLock(context.Background(), dir, "init.lock")
+	if err != nil {
+		return err
+	}
+	defer unlock.Close()
+
+	return s.initializeLocal(dir)
+}
+
+// waitForLock acquires the named lock file in dir, polling until any other
+// process holding it releases it or the context is cancelled.
+func waitForLock(ctx context.Context, dir, name string) (io.Closer, error) {
+	for {
+		closer, err := fslock.Lock(dir, name)
+		var locked fslock.LockedError
+		if err == nil {
+			return closer, nil
+		} else if !errors.As(err, &locked) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for lock on %q: %w", dir, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
 	}
-	return nil
 }
 
 func (s *Ipfs) initializeLocal(dir string) error {
@@ -71,6 +128,7 @@ func (s *Ipfs) initializeLocal(dir string) error {
 	if err != nil {
 		return fmt.Errorf("failed to generate default IPFS config: %v", err)
 	}
+	cfg.Experimental.FilestoreEnabled = true
 
 	if err := fsrepo.Init(dir, cfg); err != nil {
 		return fmt.Errorf("failed to initialize IPFS repo: %v", err)
@@ -82,7 +140,10 @@ func (s *Ipfs) initializeLocal(dir string) error {
 func (s *Ipfs) Connect(ctx context.Context, dir string, options ...IpfsNodeOption) error {
 	s.options = options
 	if !s.config.Global {
-		return s.connectLocal(ctx, dir, options...)
+		if s.connectDaemon(ctx, dir) {
+			return nil
+		}
+		return s.connectLocal(ctx, s.repoPath(dir), options...)
 	} else {
 		return s.connectGlobal(ctx)
 	}
@@ -159,12 +220,55 @@ func IpfsOffline(cfg *kubo.BuildCfg) *kubo.BuildCfg {
 	return cfg
 }
 
-func createNode(ctx context.Context, dir string, options ...IpfsNodeOption) (*kubo.IpfsNode, error) {
-	repo, err := fsrepo.Open(dir)
+// openRepo opens the IPFS repository at dir. If another dorothy process
+// holds the repository, as happens when several datasets share one, it waits
+// up to timeout for that process to finish. This only serializes processes;
+// it does not queue them, so a long-running command can make others time out.
+// Shared repositories are normally reached through a daemon, which lets
+// several processes use the repository at once, and are only opened here
+// when no daemon could be started.
+func openRepo(ctx context.Context, dir string, timeout time.Duration) (repo.Repo, error) {
+	var deadline <-chan time.Time
+	for {
+		r, err := fsrepo.Open(dir)
+		if err == nil {
+			return r, nil
+		} else if locked, lerr := fsrepo.LockedByOtherProcess(dir); lerr != nil || !locked {
+			return nil, err
+		}
+
+		if deadline == nil {
+			fmt.Fprintf(os.Stderr, "waiting for repository lock on %q held by another dorothy process...\n", dir)
+			deadline = time.After(timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for lock on %q: %w", dir, ctx.Err())
+		case <-deadline:
+			return nil, fmt.Errorf("timed out after %v waiting for lock on %q; run `dorothy daemon start` so that several dorothy processes can use it at once", timeout, dir)
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func createNode(ctx context.Context, dir string, timeout time.Duration, options ...IpfsNodeOption) (*kubo.IpfsNode, error) {
+	repo, err := openRepo(ctx, dir, timeout)
 	if err != nil {
 		return nil, err
 	}
 
+	if changed, err := enableFilestore(repo); err != nil {
+		return nil, err
+	} else if changed {
+		if err := repo.Close(); err != nil {
+			return nil, err
+		}
+		if repo, err = fsrepo.Open(dir); err != nil {
+			return nil, err
+		}
+	}
+
 	cfg := &kubo.BuildCfg{
 		Online:  true,
 		Routing: libp2p.DHTOption,
@@ -183,7 +287,12 @@ func (s *Ipfs) connectLocal(ctx context.Context, filepath string, options ...Ipf
 		return err
 	}
 
-	s.node, err = createNode(ctx, filepath, options...)
+	timeout, err := s.config.RepoLockTimeout()
+	if err != nil {
+		return err
+	}
+
+	s.node, err = createNode(ctx, filepath, timeout, options...)
 	if err != nil {
 		return fmt.Errorf("failed to instantiate local IPFS node: %v", err)
 	}
@@ -272,39 +381,28 @@ func getUnixFileNode(filepath string) (files.Node, error) {
 	return files.NewSerialFile(filepath, true, stat)
 }
 
-func (s *Ipfs) Add(ctx context.Context, filename string, options ...options.UnixfsAddOption) (string, error) {
-	filenode, err := getUnixFileNode(filename)
-	if err != nil {
-		return "", err
-	}
-
-	cidfile, err := s.Unixfs().Add(ctx, filenode, options...)
-
-	if err != nil {
-		return "", err
-	}
-
-	return cidfile.RootCid().String(), nil
-}
-
-func (s Ipfs) AddMany(ctx context.Context, filenames []string, options ...options.UnixfsAddOption) (string, error) {
+// getUnixFileNodes returns a directory which holds each of the files under
+// its base name.
+func getUnixFileNodes(filenames []string) (files.Node, error) {
 	if len(filenames) == 0 {
-		return "", fmt.Errorf("no files provided")
+		return nil, fmt.Errorf("no files provided")
 	}
 
 	var entries []files.DirEntry
 	for _, path := range filenames {
 		node, err := getUnixFileNode(path)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		entries = append(entries, files.FileEntry(filepath.Base(path), node))
 	}
 
-	dir := files.NewSliceDirectory(entries)
+	return files.NewSliceDirectory(entries), nil
+}
 
-	cidfile, err := s.Unixfs().Add(ctx, dir, options...)
+func (s *Ipfs) AddNode(ctx context.Context, node files.Node, options ...options.UnixfsAddOption) (string, error) {
+	cidfile, err := s.Unixfs().Add(ctx, node, options...)
 
 	if err != nil {
 		return "", err
@@ -313,6 +411,24 @@ func (s Ipfs) AddMany(ctx context.Context, filenames []string, options ...option
 	return cidfile.RootCid().String(), nil
 }
 
+func (s *Ipfs) Add(ctx context.Context, filename string, options ...options.UnixfsAddOption) (string, error) {
+	filenode, err := getUnixFileNode(filename)
+	if err != nil {
+		return "", err
+	}
+
+	return s.AddNode(ctx, filenode, options...)
+}
+
+func (s Ipfs) AddMany(ctx context.Context, filenames []string, options ...options.UnixfsAddOption) (string, error) {
+	dir, err := getUnixFileNodes(filenames)
+	if err != nil {
+		return "", err
+	}
+
+	return s.AddNode(ctx, dir, options...)
+}
+
 func (s *Ipfs) ConnectToPeerById(ctx context.Context, id peer.ID) error {
 	addrInfo, err := s.Routing().FindPeer(ctx, id)
 	if err != nil {
@@ -322,6 +438,12 @@ func (s *Ipfs) ConnectToPeerById(ctx context.Context, id peer.ID) error {
 }
 
 func (s Ipfs) MergeAndCommit(ctx context.Context, old, new *Manifest) (*Manifest, []Conflict, error) {
+	return s.mergeAndCommit(ctx, old, new, nil)
+}
+
+// mergeAndCommit merges new into old, pinning each version which new adds
+// unless it is in nopin.
+func (s Ipfs) mergeAndCommit(ctx context.Context, old, new *Manifest, nopin map[string]bool) (*Manifest, []Conflict, error) {
 	merged, conflicts, err := old.Merge(new)
 	if err != nil || len(conflicts) != 0 {
 		return nil, conflicts, err
@@ -334,6 +456,9 @@ func (s Ipfs) MergeAndCommit(ctx context.Context, old, new *Manifest) (*Manifest
 
 	errs := make([]error, 0, len(delta))
 	for _, version := range delta {
+		if nopin[version.Hash] {
+			continue
+		}
 		_, err := s.CommitVersion(ctx, version)
 		if err != nil {
 			errs = append(errs, err)
@@ -358,5 +483,13 @@ func (s Ipfs) CommitVersion(ctx context.Context, version *Version) (string, erro
 	if err != nil {
 		return "", err
 	}
-	return version.Hash, s.Pin().Add(ctx, versionPath)
+	if err := s.Pin().Add(ctx, versionPath); err != nil || version.Checksums == "" {
+		return version.Hash, err
+	}
+
+	checksumsPath, err := path.NewPath("/ipfs/" + version.Checksums)
+	if err != nil {
+		return "", err
+	}
+	return version.Hash, s.Pin().Add(ctx, checksumsPath)
 }
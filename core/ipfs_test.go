@@ -1,10 +1,18 @@
 package core
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/adrg/xdg"
 )
 
 func setup(t *testing.T) (*Ipfs, context.Context) {
@@ -185,3 +193,130 @@ func TestMultipleCommits(t *testing.T) {
 		}
 	}
 }
+
+func TestSharedRepo(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := NewIpfs(&IpfsConfig{Shared: true})
+	if err := first.Initialize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Connect(ctx, t.TempDir(), IpfsOffline); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := first.CreateEmptyManifest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	second := NewIpfs(&IpfsConfig{Shared: true})
+	if err := second.Initialize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := second.Connect(ctx, t.TempDir(), IpfsOffline); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := second.GetManifest(ctx, manifest.Hash); err != nil {
+		t.Errorf("expected the manifest to be in the shared repo: %v", err)
+	}
+}
+
+// TestHoldSharedRepo is run in a subprocess by TestSharedRepoLockTimeout to
+// hold the shared repo until its standard input is closed.
+func TestHoldSharedRepo(t *testing.T) {
+	if os.Getenv("DOROTHY_HOLD_SHARED_REPO") == "" {
+		t.Skip("only run as a subprocess")
+	}
+
+	held := NewIpfs(&IpfsConfig{Shared: true})
+	if err := held.Connect(context.Background(), t.TempDir(), IpfsOffline); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("holding")
+	io.Copy(io.Discard, os.Stdin)
+}
+
+func TestSharedRepoLockTimeout(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	first := NewIpfs(&IpfsConfig{Shared: true})
+	if err := first.Initialize(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	holder := exec.Command(os.Args[0], "-test.run=^TestHoldSharedRepo$", "-test.v")
+	holder.Env = append(os.Environ(), "DOROTHY_HOLD_SHARED_REPO=1")
+	stdin, err := holder.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := holder.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := holder.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		holder.Wait()
+	})
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() && scanner.Text() != "holding" {
+	}
+	go io.Copy(io.Discard, stdout)
+
+	second := NewIpfs(&IpfsConfig{Shared: true, LockTimeout: "300ms"})
+	err = second.Connect(context.Background(), t.TempDir(), IpfsOffline)
+	if err == nil || !strings.Contains(err.Error(), "dorothy daemon start") {
+		t.Errorf("expected to time out waiting for the held repo, got %v", err)
+	}
+
+	if _, err := (IpfsConfig{LockTimeout: "soon"}).RepoLockTimeout(); err == nil {
+		t.Errorf("expected an invalid lock timeout to be rejected")
+	}
+	if timeout, err := (IpfsConfig{}).RepoLockTimeout(); err != nil || timeout != DefaultRepoLockTimeout {
+		t.Errorf("expected the default lock timeout, got %v, %v", timeout, err)
+	}
+}
+
+func TestWaitForLock(t *testing.T) {
+	dir := t.TempDir()
+
+	held, err := waitForLock(context.Background(), dir, "test.lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if _, err := waitForLock(ctx, dir, "test.lock"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected to time out waiting for a held lock, got %v", err)
+	}
+
+	if err := held.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	lock, err := waitForLock(ctx, dir, "test.lock")
+	if err != nil {
+		t.Fatalf("expected to acquire a released lock: %v", err)
+	}
+	lock.Close()
+}
@@ -0,0 +1,315 @@
+package core
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type GraphFormat string
+
+const (
+	GraphFormatDot     GraphFormat = "dot"
+	GraphFormatMermaid GraphFormat = "mermaid"
+	GraphFormatGraphML GraphFormat = "graphml"
+	GraphFormatSvg     GraphFormat = "svg"
+)
+
+var AllGraphFormat = []GraphFormat{
+	GraphFormatDot,
+	GraphFormatMermaid,
+	GraphFormatGraphML,
+	GraphFormatSvg,
+}
+
+func (f GraphFormat) IsValid() bool {
+	switch f {
+	case GraphFormatDot, GraphFormatMermaid, GraphFormatGraphML, GraphFormatSvg:
+		return true
+	}
+	return false
+}
+
+func (f GraphFormat) String() string {
+	return string(f)
+}
+
+func (f GraphFormat) ContentType() string {
+	switch f {
+	case GraphFormatDot:
+		return "text/vnd.graphviz"
+	case GraphFormatGraphML:
+		return "application/graphml+xml"
+	case GraphFormatSvg:
+		return "image/svg+xml"
+	}
+	return "text/plain"
+}
+
+const dateFormat = "Mon Jan 02 15:04:05 2006 -0700"
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+func (m *Manifest) edges() [][2]string {
+	known := make(map[string]bool)
+	for _, version := range m.Versions {
+		known[version.Hash] = true
+	}
+
+	var edges [][2]string
+	for _, version := range m.Versions {
+		for _, parent := range version.Parents {
+			if known[parent] {
+				edges = append(edges, [2]string{parent, version.Hash})
+			}
+		}
+	}
+	return edges
+}
+
+// graphLabel labels a version with its short hash, message, author and tags.
+func graphLabel(version *Version, tags []string) string {
+	label := fmt.Sprintf("%s\n%s\n%s", shortHash(version.Hash), version.Message, version.Author)
+	if len(tags) != 0 {
+		label += "\n[" + strings.Join(tags, ", ") + "]"
+	}
+	return label
+}
+
+// WriteGraph writes the version graph in the given format, with the tags of
+// each version, if any, as attributes of its node.
+func (m *Manifest) WriteGraph(w io.Writer, format GraphFormat, tags Tags) error {
+	switch format {
+	case GraphFormatDot:
+		return m.writeDot(w, tags)
+	case GraphFormatMermaid:
+		return m.writeMermaid(w, tags)
+	case GraphFormatGraphML:
+		return m.writeGraphML(w, tags)
+	case GraphFormatSvg:
+		return m.writeSvg(w, tags)
+	}
+	return fmt.Errorf("unsupported graph format %q", format)
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
+
+func (m *Manifest) writeDot(w io.Writer, tags Tags) error {
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "digraph manifest {")
+	fmt.Fprintln(b, "  rankdir=BT;")
+	fmt.Fprintln(b, "  node [shape=box];")
+	for _, version := range m.Versions {
+		names := tags.For(version.Hash)
+		fmt.Fprintf(
+			b,
+			"  %s [label=%s, message=%s, author=%s, date=%s, path_type=%s, tags=%s];\n",
+			dotQuote(version.Hash),
+			dotQuote(graphLabel(version, names)),
+			dotQuote(version.Message),
+			dotQuote(version.Author),
+			dotQuote(version.Date.Format(dateFormat)),
+			dotQuote(version.PathType.String()),
+			dotQuote(strings.Join(names, ",")),
+		)
+	}
+	for _, edge := range m.edges() {
+		fmt.Fprintf(b, "  %s -> %s;\n", dotQuote(edge[0]), dotQuote(edge[1]))
+	}
+	fmt.Fprintln(b, "}")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mermaidQuote(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", "<br/>", "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
+
+func (m *Manifest) writeMermaid(w io.Writer, tags Tags) error {
+	ids := make(map[string]string)
+	for i, version := range m.Versions {
+		ids[version.Hash] = fmt.Sprintf("v%d", i)
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "flowchart BT")
+	for _, version := range m.Versions {
+		label := graphLabel(version, tags.For(version.Hash))
+		fmt.Fprintf(b, "  %s[%s]\n", ids[version.Hash], mermaidQuote(label))
+	}
+	for _, edge := range m.edges() {
+		fmt.Fprintf(b, "  %s --> %s\n", ids[edge[0]], ids[edge[1]])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphmlKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+func (m *Manifest) writeGraphML(w io.Writer, tags Tags) error {
+	doc := graphmlDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphmlKey{
+			{ID: "message", For: "node", AttrName: "message", AttrType: "string"},
+			{ID: "author", For: "node", AttrName: "author", AttrType: "string"},
+			{ID: "date", For: "node", AttrName: "date", AttrType: "string"},
+			{ID: "path_type", For: "node", AttrName: "path_type", AttrType: "string"},
+			{ID: "tags", For: "node", AttrName: "tags", AttrType: "string"},
+		},
+		Graph: graphmlGraph{
+			ID:          "manifest",
+			EdgeDefault: "directed",
+		},
+	}
+
+	for _, version := range m.Versions {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphmlNode{
+			ID: version.Hash,
+			Data: []graphmlData{
+				{Key: "message", Value: version.Message},
+				{Key: "author", Value: version.Author},
+				{Key: "date", Value: version.Date.Format(dateFormat)},
+				{Key: "path_type", Value: version.PathType.String()},
+				{Key: "tags", Value: strings.Join(tags.For(version.Hash), ",")},
+			},
+		})
+	}
+	for _, edge := range m.edges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{Source: edge[0], Target: edge[1]})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func xmlEscape(s string) string {
+	b := &strings.Builder{}
+	xml.EscapeText(b, []byte(s))
+	return b.String()
+}
+
+func (m *Manifest) writeSvg(w io.Writer, tags Tags) error {
+	const (
+		laneWidth = 24
+		rowHeight = 40
+		margin    = 20
+		radius    = 6
+		textWidth = 480
+	)
+
+	entries := m.Graph()
+
+	lanes := 1
+	position := make(map[string][2]int)
+	for row, entry := range entries {
+		lane := strings.IndexByte(entry.Node, '*') / 2
+		lanes = max(lanes, lane+1, (len(entry.Node)+1)/2)
+		position[entry.Version.Hash] = [2]int{
+			margin + lane*laneWidth,
+			margin + row*rowHeight,
+		}
+	}
+
+	width := 2*margin + lanes*laneWidth + textWidth
+	height := 2*margin + max(len(entries)-1, 0)*rowHeight
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintln(b, `  <g class="edges" stroke="#555" stroke-width="2" fill="none">`)
+	for _, edge := range m.edges() {
+		from, to := position[edge[1]], position[edge[0]]
+		if from[0] == to[0] {
+			fmt.Fprintf(b, `    <line x1="%d" y1="%d" x2="%d" y2="%d"/>`+"\n", from[0], from[1], to[0], to[1])
+		} else {
+			bend := from[1] + rowHeight/2
+			if from[0] < to[0] {
+				bend = to[1] - rowHeight/2
+			}
+			fmt.Fprintf(
+				b,
+				`    <polyline points="%d,%d %d,%d %d,%d %d,%d"/>`+"\n",
+				from[0], from[1], from[0], bend, to[0], bend, to[0], to[1],
+			)
+		}
+	}
+	fmt.Fprintln(b, `  </g>`)
+	fmt.Fprintln(b, `  <g class="versions" font-family="monospace" font-size="12">`)
+	textX := margin + lanes*laneWidth
+	for _, entry := range entries {
+		version := entry.Version
+		p := position[version.Hash]
+		names := tags.For(version.Hash)
+		fmt.Fprintf(
+			b,
+			`    <g class="version" data-hash="%s" data-tags="%s">`+"\n",
+			xmlEscape(version.Hash), xmlEscape(strings.Join(names, ",")),
+		)
+		fmt.Fprintf(b, `      <title>%s</title>`+"\n", xmlEscape(version.Hash+"\n"+version.Author+"\n"+version.Date.Format(dateFormat)))
+		fmt.Fprintf(b, `      <circle cx="%d" cy="%d" r="%d" fill="#222"/>`+"\n", p[0], p[1], radius)
+		text := fmt.Sprintf("%s %s (%s)", shortHash(version.Hash), version.Message, version.Author)
+		if len(names) != 0 {
+			text += " [" + strings.Join(names, ", ") + "]"
+		}
+		fmt.Fprintf(b, `      <text x="%d" y="%d" dominant-baseline="middle">%s</text>`+"\n", textX, p[1], xmlEscape(text))
+		fmt.Fprintln(b, `    </g>`)
+	}
+	fmt.Fprintln(b, `  </g>`)
+	fmt.Fprintln(b, `</svg>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
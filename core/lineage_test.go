@@ -0,0 +1,127 @@
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteGraphDot(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"}, []string{"c", "a", "b"})
+
+	var buf bytes.Buffer
+	if err := manifest.WriteGraph(&buf, GraphFormatDot, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "digraph manifest {\n") {
+		t.Errorf("expected a digraph, got %q", got)
+	}
+	for _, edge := range []string{`"a" -> "b";`, `"a" -> "c";`, `"b" -> "c";`} {
+		if !strings.Contains(got, edge) {
+			t.Errorf("expected edge %s in %q", edge, got)
+		}
+	}
+	if !strings.Contains(got, `author="Douglas G. Moore <doug@dglmoore.com>"`) {
+		t.Errorf("expected author attribute in %q", got)
+	}
+}
+
+func TestWriteGraphDotEscapesMessages(t *testing.T) {
+	manifest := graphManifest(t, []string{`say "hi"`})
+
+	var buf bytes.Buffer
+	if err := manifest.WriteGraph(&buf, GraphFormatDot, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), `message="say \"hi\""`) {
+		t.Errorf("expected escaped message in %q", buf.String())
+	}
+}
+
+func TestWriteGraphMermaid(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"})
+
+	var buf bytes.Buffer
+	if err := manifest.WriteGraph(&buf, GraphFormatMermaid, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "flowchart BT\n") {
+		t.Errorf("expected a flowchart, got %q", got)
+	}
+	if !strings.Contains(got, "  v0 --> v1\n") {
+		t.Errorf("expected edge v0 --> v1 in %q", got)
+	}
+}
+
+func TestWriteGraphGraphML(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"})
+
+	var buf bytes.Buffer
+	if err := manifest.WriteGraph(&buf, GraphFormatGraphML, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc graphmlDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Graph.Nodes) != 2 {
+		t.Errorf("expected 2 nodes, got %d", len(doc.Graph.Nodes))
+	}
+	if len(doc.Graph.Edges) != 1 || doc.Graph.Edges[0].Source != "a" || doc.Graph.Edges[0].Target != "b" {
+		t.Errorf("expected a single edge a -> b, got %v", doc.Graph.Edges)
+	}
+}
+
+func TestWriteGraphSvg(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b & c", "a"})
+
+	var buf bytes.Buffer
+	if err := manifest.WriteGraph(&buf, GraphFormatSvg, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("expected well-formed svg: %v", err)
+	}
+	if doc.XMLName.Local != "svg" {
+		t.Errorf("expected svg root element, got %q", doc.XMLName.Local)
+	}
+}
+
+func TestWriteGraphTags(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"})
+	tags := Tags{"v1": "a", "first": "a"}
+
+	expected := map[GraphFormat]string{
+		GraphFormatDot:     `tags="first,v1"`,
+		GraphFormatMermaid: "[first, v1]",
+		GraphFormatGraphML: `<data key="tags">first,v1</data>`,
+		GraphFormatSvg:     `data-tags="first,v1"`,
+	}
+	for format, attribute := range expected {
+		var buf bytes.Buffer
+		if err := manifest.WriteGraph(&buf, format, tags); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), attribute) {
+			t.Errorf("%s: expected %s in %q", format, attribute, buf.String())
+		}
+	}
+}
+
+func TestWriteGraphInvalidFormat(t *testing.T) {
+	manifest := &Manifest{}
+	if err := manifest.WriteGraph(&bytes.Buffer{}, GraphFormat("png"), nil); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
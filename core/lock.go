@@ -0,0 +1,210 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const (
+	// LockStaleAfter is how long a repository lock may go without being
+	// refreshed by its holder before other processes consider it abandoned.
+	LockStaleAfter      = 2 * time.Minute
+	lockRefreshInterval = 15 * time.Second
+)
+
+type lockInfo struct {
+	Host     string    `json:"host"`
+	Pid      int       `json:"pid"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// RepositoryLock is held by operations which modify the contents of the
+// .dorothy directory. It is a plain file rather than an OS-level lock so that
+// it works for repositories on network filesystems.
+type RepositoryLock struct {
+	path string
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (d *Dorothy) LockPath() string {
+	return filepath.Join(d.Directory, "lock")
+}
+
+// Lock acquires the repository lock, waiting for other processes to release
+// it. Locks left behind by processes which have died are removed.
+func (d *Dorothy) Lock() (*RepositoryLock, error) {
+	path := d.LockPath()
+
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	info, err := json.Marshal(lockInfo{Host: host, Pid: os.Getpid(), Acquired: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		handle, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = handle.Write(info)
+			if err == nil {
+				err = handle.Sync()
+			}
+			if cerr := handle.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+
+			lock := &RepositoryLock{
+				path: path,
+				stop: make(chan struct{}),
+				done: make(chan struct{}),
+			}
+			go lock.refresh()
+			return lock, nil
+		} else if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if err := removeStaleLock(path, host); err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-d.Done():
+			return nil, fmt.Errorf("timed out waiting for repository lock %q: %w", path, d.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (l *RepositoryLock) refresh() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(lockRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+func (l *RepositoryLock) Unlock() error {
+	close(l.stop)
+	<-l.done
+	return os.Remove(l.path)
+}
+
+func readLockInfo(path string) (lockInfo, time.Time, error) {
+	var info lockInfo
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return info, time.Time{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return info, time.Time{}, err
+	}
+
+	// A lock which cannot be parsed may still be being written, so it is only
+	// judged by its age.
+	json.Unmarshal(content, &info)
+
+	return info, stat.ModTime(), nil
+}
+
+func isStaleLock(info lockInfo, modified time.Time, host string) bool {
+	if info.Host == host && info.Pid > 0 && !processExists(info.Pid) {
+		return true
+	}
+	return time.Since(modified) > LockStaleAfter
+}
+
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// removeStaleLock removes the lock at path if its holder has gone away. The
+// lock is first moved aside so that, if another process replaced it in the
+// meantime, the fresh lock can be put back rather than deleted.
+func removeStaleLock(path, host string) error {
+	info, modified, err := readLockInfo(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if !isStaleLock(info, modified, host) {
+		return nil
+	}
+
+	aside := fmt.Sprintf("%s.stale-%d", path, os.Getpid())
+	if err := os.Rename(path, aside); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer os.Remove(aside)
+
+	if moved, _, err := readLockInfo(aside); err == nil && moved != info {
+		os.Link(aside, path)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces filename with data such that readers see either the
+// old or the new contents, and the new contents survive a crash once it
+// returns.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+
+	handle, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+	return handle.Sync()
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newLockTestDorothy(t *testing.T) *Dorothy {
+	t.Helper()
+
+	return &Dorothy{
+		Context:   context.Background(),
+		Directory: t.TempDir(),
+	}
+}
+
+func writeLock(t *testing.T, d *Dorothy, info lockInfo, modified time.Time) {
+	t.Helper()
+
+	content, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(d.LockPath(), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(d.LockPath(), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	d := newLockTestDorothy(t)
+
+	lock, err := d.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	other := &Dorothy{Context: ctx, Directory: d.Directory}
+	if _, err := other.Lock(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected to time out waiting for a held lock, got %v", err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(d.LockPath()); !os.IsNotExist(err) {
+		t.Errorf("expected the lock file to be removed")
+	}
+
+	lock, err = d.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Unlock()
+}
+
+func TestLockStale(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		info     lockInfo
+		modified time.Time
+		stale    bool
+	}{
+		{"live", lockInfo{Host: host, Pid: os.Getpid()}, time.Now(), false},
+		{"dead process", lockInfo{Host: host, Pid: 1 << 30}, time.Now(), true},
+		{"other host", lockInfo{Host: host + ".example", Pid: 1 << 30}, time.Now(), false},
+		{"abandoned", lockInfo{Host: host + ".example", Pid: 1}, time.Now().Add(-2 * LockStaleAfter), true},
+		{"unknown holder", lockInfo{}, time.Now(), false},
+	}
+
+	for _, c := range cases {
+		d := newLockTestDorothy(t)
+		writeLock(t, d, c.info, c.modified)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+		d.Context = ctx
+		lock, err := d.Lock()
+		cancel()
+
+		if c.stale {
+			if err != nil {
+				t.Errorf("%s: expected the stale lock to be replaced, got %v", c.name, err)
+				continue
+			}
+			lock.Unlock()
+		} else if err == nil {
+			t.Errorf("%s: expected the lock to be respected", c.name)
+			lock.Unlock()
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "manifest")
+	for _, content := range []string{"first", "second"} {
+		if err := writeFileAtomic(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("expected %q, got %q", content, got)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected temporary files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func TestMergeManifestReloads(t *testing.T) {
+	client, ctx := setup(t)
+
+	manifest, err := client.CreateEmptyManifest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	first := &Dorothy{Context: ctx, Directory: dir, Ipfs: *client, Manifest: manifest}
+	if err := first.WriteManifestFile(ReflogInit, ""); err != nil {
+		t.Fatal(err)
+	}
+	second := &Dorothy{Context: ctx, Directory: dir, Ipfs: *client, Manifest: manifest}
+
+	for i, d := range []*Dorothy{first, second} {
+		hash := addTree(t, client, ctx, map[string]string{"data.csv": string(rune('a' + i))})
+		version := &Version{Author: "X <x@y>", Date: time.Now(), Message: "commit", Hash: hash, PathType: PathTypeDirectory}
+		if conflicts, err := d.MergeManifest(&Manifest{Versions: []*Version{version}}, ReflogCommit, "commit"); err != nil || len(conflicts) != 0 {
+			t.Fatalf("unexpected merge failure: %v %v", conflicts, err)
+		}
+	}
+
+	if len(second.Manifest.Versions) != 2 {
+		t.Errorf("expected both versions to be kept, got %d", len(second.Manifest.Versions))
+	}
+}
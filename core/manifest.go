@@ -62,13 +62,21 @@ type Version struct {
 	Hash     string    `json:"hash"`
 	PathType PathType  `json:"path_type"`
 	Parents  []string  `json:"parents"`
+	// Checksums is the hash of the sidecar object which records the
+	// checksums of the version's files, if they were computed at commit.
+	Checksums string `json:"checksums,omitempty"`
+	// Summary records the size of the version when it was committed, so
+	// that it can be shown without walking the DAG.
+	Summary *TreeSummary `json:"summary,omitempty"`
+	// Add records the settings with which the version was added to IPFS.
+	Add *AddSettings `json:"add,omitempty"`
 }
 
 func (v *Version) IpfsPath() (path.ImmutablePath, error) {
 	if v.Hash == "" {
 		return path.ImmutablePath{}, fmt.Errorf("version does has an empty hash")
 	}
-	ipfsPath, err := path.NewPath(v.Hash)
+	ipfsPath, err := path.NewPath("/ipfs/" + v.Hash)
 	if err != nil {
 		return path.ImmutablePath{}, err
 	}
@@ -94,13 +102,58 @@ func (v *Version) SameParents(o *Version) bool {
 	return true
 }
 
+func (v *Version) summaryString() string {
+	if v.Summary == nil {
+		return ""
+	}
+	return v.Summary.String()
+}
+
+// Equal reports whether v and o describe the same version. Checksums, sizes
+// and add settings which only one of them records do not make them differ,
+// since they may have been recorded after the version was first shared; see
+// withMetadata.
 func (v *Version) Equal(o *Version) bool {
 	return v.SameHash(o) &&
 		v.Author == o.Author &&
 		v.Date.Equal(o.Date) &&
 		v.Message == o.Message &&
 		v.PathType == o.PathType &&
-		v.SameParents(o)
+		v.SameParents(o) &&
+		v.SameChecksums(o) &&
+		v.SameSummary(o) &&
+		v.SameAddSettings(o)
+}
+
+func (v *Version) SameChecksums(o *Version) bool {
+	return v.Checksums == "" || o.Checksums == "" || v.Checksums == o.Checksums
+}
+
+func (v *Version) SameAddSettings(o *Version) bool {
+	return v.Add == nil || o.Add == nil || *v.Add == *o.Add
+}
+
+// withMetadata returns v with the checksums, size and add settings which only
+// o records filled in, or v itself if o adds nothing.
+func (v *Version) withMetadata(o *Version) *Version {
+	if (v.Checksums != "" || o.Checksums == "") && (v.Summary != nil || o.Summary == nil) && (v.Add != nil || o.Add == nil) {
+		return v
+	}
+	merged := *v
+	if merged.Checksums == "" {
+		merged.Checksums = o.Checksums
+	}
+	if merged.Summary == nil {
+		merged.Summary = o.Summary
+	}
+	if merged.Add == nil {
+		merged.Add = o.Add
+	}
+	return &merged
+}
+
+func (v *Version) SameSummary(o *Version) bool {
+	return v.Summary == nil || o.Summary == nil || *v.Summary == *o.Summary
 }
 
 func (v *Version) Less(o *Version) bool {
@@ -116,7 +169,7 @@ func (m *Manifest) IpfsPath() (path.ImmutablePath, error) {
 	if m.Hash == "" {
 		return path.ImmutablePath{}, fmt.Errorf("manifest does not have a loaded hash")
 	}
-	ipfsPath, err := path.NewPath(m.Hash)
+	ipfsPath, err := path.NewPath("/ipfs/" + m.Hash)
 	if err != nil {
 		return path.ImmutablePath{}, err
 	}
@@ -137,7 +190,7 @@ func (old *Manifest) Diff(new *Manifest) ([]*Version, error) {
 	for _, newverison := range new.Versions {
 		found := false
 		for _, oldverison := range old.Versions {
-			if newverison.Equal(oldverison) {
+			if newverison.Equal(oldverison) && oldverison.withMetadata(newverison) == oldverison {
 				found = true
 				break
 			}
@@ -159,6 +212,11 @@ func (old *Manifest) Merge(new *Manifest) (*Manifest, []Conflict, error) {
 	var updated []*Version
 
 	for _, verison := range old.Versions {
+		for _, newverison := range new.Versions {
+			if newverison.Equal(verison) {
+				verison = verison.withMetadata(newverison)
+			}
+		}
 		updated = append(updated, verison)
 	}
 	for _, newverison := range new.Versions {
@@ -203,6 +261,64 @@ func (manifest *Manifest) UnknownCommits(commits []string) []string {
 	return unknown
 }
 
+func (manifest *Manifest) FindVersion(hash string) (*Version, error) {
+	for _, version := range manifest.Versions {
+		if version.Hash == hash {
+			return version, nil
+		}
+	}
+
+	var matches []*Version
+	for _, version := range manifest.Versions {
+		if strings.HasPrefix(version.Hash, hash) {
+			matches = append(matches, version)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("hash %q not found in manifest", hash)
+	} else if len(matches) > 1 {
+		return nil, fmt.Errorf("hash matches multiple commits; aborting")
+	}
+
+	return matches[0], nil
+}
+
+func (manifest *Manifest) Children(hash string) []*Version {
+	var children []*Version
+	for _, version := range manifest.Versions {
+		for _, parent := range version.Parents {
+			if parent == hash {
+				children = append(children, version)
+				break
+			}
+		}
+	}
+	return children
+}
+
+// Ancestors returns the hashes of the version and every version it descends
+// from.
+func (manifest *Manifest) Ancestors(hash string) map[string]bool {
+	parents := make(map[string][]string)
+	for _, version := range manifest.Versions {
+		parents[version.Hash] = append(parents[version.Hash], version.Parents...)
+	}
+
+	ancestors := make(map[string]bool)
+	queue := []string{hash}
+	for len(queue) != 0 {
+		h := queue[0]
+		queue = queue[1:]
+		if ancestors[h] {
+			continue
+		}
+		ancestors[h] = true
+		queue = append(queue, parents[h]...)
+	}
+	return ancestors
+}
+
 func (manifest *Manifest) LeafVersions() []*Version {
 	isParent := make(map[string]bool)
 	for _, version := range manifest.Versions {
@@ -220,6 +336,21 @@ func (manifest *Manifest) LeafVersions() []*Version {
 	return leaves
 }
 
+// LatestVersion returns the most recently dated leaf of the manifest, which
+// is what a request that names no revision refers to.
+func (manifest *Manifest) LatestVersion() (*Version, error) {
+	var latest *Version
+	for _, leaf := range manifest.LeafVersions() {
+		if latest == nil || leaf.Date.After(latest.Date) {
+			latest = leaf
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("manifest has no versions")
+	}
+	return latest, nil
+}
+
 type Conflict struct {
 	Left  *Version
 	Right *Version
@@ -257,6 +388,8 @@ func (c Conflict) String() string {
 	)
 	fmt.Fprintf(t, "    %s\t%s\t%s\n", bold("Message:"), c.Left.Message, c.Right.Message)
 	fmt.Fprintf(t, "    %s\t%s\t%s\n", bold("Type:"), c.Left.PathType.String(), c.Right.PathType.String())
+	fmt.Fprintf(t, "    %s\t%s\t%s\n", bold("Size:"), c.Left.summaryString(), c.Right.summaryString())
+	fmt.Fprintf(t, "    %s\t%s\t%s\n", bold("Checksums:"), c.Left.Checksums, c.Right.Checksums)
 
 	label := "Parents:"
 	if len(c.Left.Parents) == 0 && len(c.Right.Parents) == 0 {
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestFindVersion(t *testing.T) {
+	manifest := graphManifest(t, []string{"abc1"}, []string{"abc2", "abc1"}, []string{"def", "abc2"})
+
+	version, err := manifest.FindVersion("def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Hash != "def" {
+		t.Errorf("expected def, got %q", version.Hash)
+	}
+
+	version, err = manifest.FindVersion("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Hash != "def" {
+		t.Errorf("expected prefix to match def, got %q", version.Hash)
+	}
+
+	if _, err := manifest.FindVersion("abc"); err == nil {
+		t.Errorf("expected an error for an ambiguous prefix")
+	}
+
+	if _, err := manifest.FindVersion("xyz"); err == nil {
+		t.Errorf("expected an error for an unknown hash")
+	}
+}
+
+func TestChildren(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"}, []string{"c", "a"}, []string{"d", "b", "c"})
+
+	children := manifest.Children("a")
+	if len(children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(children))
+	}
+	for _, child := range children {
+		if child.Hash != "b" && child.Hash != "c" {
+			t.Errorf("unexpected child %q", child.Hash)
+		}
+	}
+
+	if children := manifest.Children("d"); len(children) != 0 {
+		t.Errorf("expected no children, got %d", len(children))
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	manifest := graphManifest(t, []string{"a"}, []string{"b", "a"}, []string{"c", "a"})
+
+	version, err := manifest.LatestVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Hash != "c" {
+		t.Errorf("expected the newest leaf c, got %q", version.Hash)
+	}
+
+	if _, err := (&Manifest{}).LatestVersion(); err == nil {
+		t.Errorf("expected an error for an empty manifest")
+	}
+}
+
+func TestConflictingMetadata(t *testing.T) {
+	base := graphManifest(t, []string{"a"}).Versions[0]
+
+	sized := *base
+	sized.Summary = &TreeSummary{Files: 1, Bytes: 10}
+	resized := *base
+	resized.Summary = &TreeSummary{Files: 1, Bytes: 0}
+	checked := *base
+	checked.Checksums = "sidecar"
+	rechecked := *base
+	rechecked.Checksums = "other"
+
+	if _, ok := (&Manifest{Versions: []*Version{base}}).Conflicts(&Manifest{Versions: []*Version{&sized}}); !ok {
+		t.Errorf("expected a missing size to be compatible")
+	}
+	if merged, _, err := (&Manifest{Versions: []*Version{base}}).Merge(&Manifest{Versions: []*Version{&sized}}); err != nil {
+		t.Fatal(err)
+	} else if len(merged.Versions) != 1 || merged.Versions[0].Summary != sized.Summary {
+		t.Errorf("expected the size to be merged in, got %+v", merged.Versions)
+	}
+	if _, ok := (&Manifest{Versions: []*Version{&checked}}).Conflicts(&Manifest{Versions: []*Version{&rechecked}}); ok {
+		t.Errorf("expected different checksums to conflict")
+	}
+
+	old := &Manifest{Versions: []*Version{base}}
+	new := &Manifest{Versions: []*Version{&checked}}
+	if delta, err := old.Diff(new); err != nil {
+		t.Fatalf("expected missing checksums to be compatible: %v", err)
+	} else if len(delta) != 1 {
+		t.Errorf("expected the version to be in the delta for its checksums, got %d", len(delta))
+	}
+	merged, _, err := old.Merge(new)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(merged.Versions) != 1 || merged.Versions[0].Checksums != "sidecar" {
+		t.Errorf("expected the checksums to be merged in, got %+v", merged.Versions)
+	} else if base.Checksums != "" {
+		t.Errorf("expected the original version not to be modified")
+	}
+	if merged, _, err := new.Merge(old); err != nil {
+		t.Fatal(err)
+	} else if len(merged.Versions) != 1 || merged.Versions[0].Checksums != "sidecar" {
+		t.Errorf("expected the checksums to be kept, got %+v", merged.Versions)
+	}
+	if _, ok := (&Manifest{Versions: []*Version{&sized}}).Conflicts(&Manifest{Versions: []*Version{&resized}}); ok {
+		t.Errorf("expected different sizes to conflict")
+	}
+
+	same := sized
+	summary := *sized.Summary
+	same.Summary = &summary
+	if _, ok := (&Manifest{Versions: []*Version{&sized}}).Conflicts(&Manifest{Versions: []*Version{&same}}); !ok {
+		t.Errorf("expected equal sizes not to conflict")
+	}
+}
@@ -0,0 +1,387 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ipfs/boxo/path"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/kubo/client/rpc"
+	"github.com/ipfs/kubo/core/coreiface/options"
+	"github.com/ipfs/kubo/core/corerepo"
+)
+
+// Tags maps names to the versions they were given to with `dorothy pin --tag`.
+type Tags map[string]string
+
+func (t Tags) For(hash string) []string {
+	var names []string
+	for name, tagged := range t {
+		if tagged == hash {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *Dorothy) TagsPath() string {
+	return filepath.Join(d.Directory, "tags.toml")
+}
+
+func (d *Dorothy) LoadTags() (Tags, error) {
+	tags := make(Tags)
+	if _, err := toml.DecodeFile(d.TagsPath(), &tags); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// updateTags applies update to the tags while holding the repository lock.
+func (d *Dorothy) updateTags(update func(Tags)) error {
+	lock, err := d.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	tags, err := d.LoadTags()
+	if err != nil {
+		return err
+	}
+	update(tags)
+
+	buffer := new(bytes.Buffer)
+	if err := toml.NewEncoder(buffer).Encode(tags); err != nil {
+		return err
+	}
+	return writeFileAtomic(d.TagsPath(), buffer.Bytes(), 0644)
+}
+
+type PinnedVersion struct {
+	*Version
+	Tags []string
+	Size uint64
+}
+
+// Pin pins a version, fetching any of its content which is not available
+// locally, and optionally tags it so that retention policies can keep it.
+func (d *Dorothy) Pin(rev, tag string) (*Version, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := d.Ipfs.CommitVersion(d, version); err != nil {
+		return nil, fmt.Errorf("cannot pin %s: %v", version.Hash, err)
+	}
+
+	if tag == "" {
+		return version, nil
+	}
+	return version, d.updateTags(func(tags Tags) {
+		tags[tag] = version.Hash
+	})
+}
+
+// Unpin unpins a version and removes its tags, so that its content can be
+// removed by `dorothy gc` unless other pinned versions share it.
+func (d *Dorothy) Unpin(rev string) (*Version, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := version.IpfsPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, pinned, err := d.Ipfs.Pin().IsPinned(d, p, options.Pin.IsPinned.Recursive()); err != nil {
+		return nil, err
+	} else if !pinned {
+		return nil, fmt.Errorf("version %s is not pinned", version.Hash)
+	}
+
+	// The pin is shared with any other dataset in the same store which has
+	// the version, so removing it would let gc collect that dataset's data.
+	referenced, err := d.referencedElsewhere()
+	if err != nil {
+		return nil, err
+	} else if referenced[version.Hash] {
+		return nil, fmt.Errorf("version %s is also in another dataset using the same IPFS store; it is kept pinned for that dataset", version.Hash)
+	}
+	if err := d.Ipfs.unpinVersion(d, version, referenced[version.Checksums]); err != nil {
+		return nil, err
+	}
+
+	return version, d.updateTags(func(tags Tags) {
+		for _, name := range tags.For(version.Hash) {
+			delete(tags, name)
+		}
+	})
+}
+
+// unpinVersion unpins a version and, unless keepChecksums, its checksums
+// sidecar if that is pinned.
+func (s *Ipfs) unpinVersion(ctx context.Context, version *Version, keepChecksums bool) error {
+	p, err := version.IpfsPath()
+	if err != nil {
+		return err
+	}
+	if err := s.Pin().Rm(ctx, p); err != nil || version.Checksums == "" || keepChecksums {
+		return err
+	}
+
+	checksumsPath, err := path.NewPath("/ipfs/" + version.Checksums)
+	if err != nil {
+		return err
+	}
+	if _, pinned, err := s.Pin().IsPinned(ctx, checksumsPath, options.Pin.IsPinned.Recursive()); err != nil || !pinned {
+		return err
+	}
+	return s.Pin().Rm(ctx, checksumsPath)
+}
+
+func (s *Ipfs) pinnedSet(ctx context.Context) (map[string]bool, error) {
+	ch, err := s.Pin().Ls(ctx, options.Pin.Ls.Recursive())
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make(map[string]bool)
+	for pin := range ch {
+		if err := pin.Err(); err != nil {
+			return nil, err
+		}
+		pinned[pin.Path().RootCid().String()] = true
+	}
+	return pinned, nil
+}
+
+// Pins lists the pinned versions of the manifest, newest first.
+func (d *Dorothy) Pins() ([]PinnedVersion, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	} else if d.Manifest == nil {
+		return nil, fmt.Errorf("no manifest found")
+	}
+
+	pinned, err := d.Ipfs.pinnedSet(d)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := d.LoadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	var pins []PinnedVersion
+	for _, version := range d.Manifest.ReverseVersions() {
+		if !pinned[version.Hash] {
+			continue
+		}
+
+		summary, err := d.Ipfs.VersionSummary(d, version)
+		if err != nil {
+			return nil, err
+		}
+
+		pins = append(pins, PinnedVersion{
+			Version: version,
+			Tags:    tags.For(version.Hash),
+			Size:    summary.Bytes,
+		})
+	}
+	return pins, nil
+}
+
+// ParseAge parses a duration such as "12h", "30d" or "8w".
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return age, nil
+}
+
+// Retained returns the hashes of the versions which the policy keeps.
+func (c RetentionConfig) Retained(manifest *Manifest, tags Tags, now time.Time) (map[string]bool, error) {
+	retained := make(map[string]bool)
+
+	versions := append([]*Version{}, manifest.Versions...)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Date.After(versions[j].Date)
+	})
+	for i := 0; i < c.KeepLast && i < len(versions); i++ {
+		retained[versions[i].Hash] = true
+	}
+
+	if c.KeepTagged {
+		for _, hash := range tags {
+			retained[hash] = true
+		}
+	}
+
+	if c.KeepNewerThan != "" {
+		age, err := ParseAge(c.KeepNewerThan)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			if now.Sub(version.Date) < age {
+				retained[version.Hash] = true
+			}
+		}
+	}
+
+	return retained, nil
+}
+
+type GcResult struct {
+	Unpinned []*Version
+	// Shared lists the versions which the retention policy does not keep
+	// but which other datasets using the same IPFS store refer to.
+	Shared  []*Version
+	Removed int
+}
+
+// GC unpins the versions which the retention policy does not keep, if one is
+// configured, and then removes unpinned blocks from the blockstore. With
+// dryRun, it only reports which versions would be unpinned.
+//
+// A shared or global IPFS store holds the unpinned blocks of other datasets
+// too, such as their --no-pin commits, so GC refuses to collect one unless
+// forced. Versions which other datasets refer to are never unpinned, since
+// their pins are shared.
+func (d *Dorothy) GC(dryRun, force bool) (*GcResult, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, fmt.Errorf("not connected to IPFS")
+	} else if d.Manifest == nil {
+		return nil, fmt.Errorf("no manifest found")
+	} else if d.Ipfs.IsShared() && !dryRun && !force {
+		return nil, fmt.Errorf("the IPFS store is shared with other datasets, whose unpinned blocks would be removed too; use --force to collect it anyway")
+	}
+
+	result := &GcResult{}
+
+	if policy := d.Config.Retention; policy != nil {
+		tags, err := d.LoadTags()
+		if err != nil {
+			return nil, err
+		}
+
+		retained, err := policy.Retained(d.Manifest, tags, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		pinned, err := d.Ipfs.pinnedSet(d)
+		if err != nil {
+			return nil, err
+		}
+
+		referenced, err := d.referencedElsewhere()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range d.Manifest.Versions {
+			if !pinned[version.Hash] || retained[version.Hash] {
+				continue
+			} else if referenced[version.Hash] {
+				result.Shared = append(result.Shared, version)
+				continue
+			}
+			if !dryRun {
+				if err := d.Ipfs.unpinVersion(d, version, referenced[version.Checksums]); err != nil {
+					return nil, err
+				}
+			}
+			pinned[version.Hash] = false
+			result.Unpinned = append(result.Unpinned, version)
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	removed, err := d.Ipfs.CollectGarbage(d)
+	result.Removed = removed
+	return result, err
+}
+
+// CollectGarbage removes every block which is not pinned from the blockstore
+// and returns how many were removed.
+func (s *Ipfs) CollectGarbage(ctx context.Context) (int, error) {
+	if api, ok := s.CoreAPI.(*rpc.HttpApi); ok {
+		return collectRemoteGarbage(ctx, api)
+	}
+
+	if s.node == nil {
+		return 0, fmt.Errorf("not connected to IPFS")
+	}
+
+	var removed int
+	err := corerepo.CollectResult(ctx, corerepo.GarbageCollectAsync(s.node, ctx), func(_ cid.Cid) {
+		removed++
+	})
+	return removed, err
+}
+
+func collectRemoteGarbage(ctx context.Context, api *rpc.HttpApi) (int, error) {
+	res, err := api.Request("repo/gc").Send(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Close()
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	var removed int
+	decoder := json.NewDecoder(res.Output)
+	for {
+		var r struct {
+			Key   map[string]string
+			Error string
+		}
+		if err := decoder.Decode(&r); err == io.EOF {
+			return removed, nil
+		} else if err != nil {
+			return removed, err
+		} else if r.Error != "" {
+			return removed, errors.New(r.Error)
+		}
+		removed++
+	}
+}
@@ -0,0 +1,278 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+func TestParseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"2d":   48 * time.Hour,
+		"1w":   7 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+	}
+	for s, expected := range cases {
+		if age, err := ParseAge(s); err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if age != expected {
+			t.Errorf("%s: expected %v, got %v", s, expected, age)
+		}
+	}
+
+	if _, err := ParseAge("soon"); err == nil {
+		t.Errorf("expected an error for an invalid age")
+	}
+}
+
+func TestRetained(t *testing.T) {
+	now := time.Now()
+	manifest := &Manifest{Versions: []*Version{
+		{Hash: "a", Date: now.Add(-40 * 24 * time.Hour)},
+		{Hash: "b", Date: now.Add(-20 * 24 * time.Hour)},
+		{Hash: "c", Date: now.Add(-10 * 24 * time.Hour)},
+		{Hash: "d", Date: now.Add(-time.Hour)},
+	}}
+	tags := Tags{"release": "a"}
+
+	cases := []struct {
+		policy   RetentionConfig
+		expected []string
+	}{
+		{RetentionConfig{}, nil},
+		{RetentionConfig{KeepLast: 2}, []string{"c", "d"}},
+		{RetentionConfig{KeepTagged: true}, []string{"a"}},
+		{RetentionConfig{KeepNewerThan: "15d"}, []string{"c", "d"}},
+		{RetentionConfig{KeepLast: 1, KeepTagged: true, KeepNewerThan: "3w"}, []string{"a", "b", "c", "d"}},
+	}
+	for _, c := range cases {
+		retained, err := c.policy.Retained(manifest, tags, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(retained) != len(c.expected) {
+			t.Errorf("%+v: expected %v, got %v", c.policy, c.expected, retained)
+		}
+		for _, hash := range c.expected {
+			if !retained[hash] {
+				t.Errorf("%+v: expected %s to be retained", c.policy, hash)
+			}
+		}
+	}
+}
+
+func TestGC(t *testing.T) {
+	client, ctx := setup(t)
+
+	var versions []*Version
+	for i, content := range []string{"old", "new"} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := client.Add(ctx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, &Version{
+			Author:   "X <x@y>",
+			Date:     time.Now().Add(time.Duration(i) * time.Minute),
+			Hash:     hash,
+			PathType: PathTypeDirectory,
+		})
+	}
+
+	empty, err := client.CreateEmptyManifest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: empty}
+	if err := d.WriteManifestFile(ReflogInit, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.MergeManifest(&Manifest{Versions: versions}, ReflogCommit, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	pins, err := d.Pins()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(pins) != 2 || pins[0].Hash != versions[1].Hash || pins[0].Size != 3 {
+		t.Fatalf("expected both versions to be pinned, newest first, got %+v", pins)
+	}
+
+	d.Config.Retention = &RetentionConfig{KeepLast: 1}
+
+	result, err := d.GC(true, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result.Unpinned) != 1 || result.Unpinned[0].Hash != versions[0].Hash || result.Removed != 0 {
+		t.Errorf("unexpected dry run result %+v", result)
+	}
+	if pins, err := d.Pins(); err != nil || len(pins) != 2 {
+		t.Errorf("expected a dry run to leave the pins alone, got %v, %v", pins, err)
+	}
+
+	if _, err := d.Pin(versions[0].Hash[:12], "first"); err != nil {
+		t.Fatal(err)
+	}
+	d.Config.Retention.KeepTagged = true
+	if result, err := d.GC(false, false); err != nil {
+		t.Fatal(err)
+	} else if len(result.Unpinned) != 0 {
+		t.Errorf("expected the tagged version to be kept, unpinned %v", result.Unpinned)
+	}
+
+	if _, err := d.Unpin("first"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := d.LoadTags(); err != nil || len(tags) != 0 {
+		t.Errorf("expected unpin to remove the tag, got %v, %v", tags, err)
+	}
+
+	result, err = d.GC(false, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if result.Removed == 0 {
+		t.Errorf("expected blocks of the unpinned version to be removed")
+	}
+	if _, err := client.Summarize(ctx, versions[0].Hash); err == nil {
+		t.Errorf("expected the unpinned version to be gone")
+	}
+	if _, err := client.Summarize(ctx, versions[1].Hash); err != nil {
+		t.Errorf("expected the retained version to remain: %v", err)
+	}
+}
+
+func TestGCSharedStore(t *testing.T) {
+	client, ctx := setup(t)
+
+	dataHome := xdg.DataHome
+	xdg.DataHome = t.TempDir()
+	t.Cleanup(func() {
+		xdg.DataHome = dataHome
+	})
+
+	shared := *client
+	shared.config.Shared = true
+
+	var versions []*Version
+	for i, content := range []string{"old", "new"} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := client.Add(ctx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, &Version{
+			Author:   "X <x@y>",
+			Date:     time.Now().Add(time.Duration(i) * time.Minute),
+			Hash:     hash,
+			PathType: PathTypeDirectory,
+		})
+	}
+
+	// Both datasets have the old version; only the first has the new one.
+	var datasets []*Dorothy
+	for _, owned := range [][]*Version{versions, versions[:1]} {
+		empty, err := client.CreateEmptyManifest(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: shared, Manifest: empty}
+		if err := os.WriteFile(d.LocalConfigPath(), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.WriteManifestFile(ReflogInit, ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.MergeManifest(&Manifest{Versions: owned}, ReflogCommit, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.register(); err != nil {
+			t.Fatal(err)
+		}
+		datasets = append(datasets, d)
+	}
+
+	d := datasets[0]
+	d.Config.Retention = &RetentionConfig{KeepLast: 1}
+
+	if _, err := d.GC(false, false); err == nil {
+		t.Fatalf("expected gc of a shared store to require --force")
+	}
+
+	result, err := d.GC(true, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result.Unpinned) != 0 || len(result.Shared) != 1 || result.Shared[0].Hash != versions[0].Hash {
+		t.Errorf("expected the version used by the other dataset to be kept, got %+v", result)
+	}
+
+	if _, err := d.GC(false, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Summarize(ctx, versions[0].Hash); err != nil {
+		t.Errorf("expected the version used by the other dataset to remain: %v", err)
+	}
+
+	if _, err := d.Unpin(versions[0].Hash); err == nil {
+		t.Errorf("expected unpin of a version used by the other dataset to fail")
+	}
+	if _, err := d.Unpin(versions[1].Hash); err != nil {
+		t.Errorf("expected a version used only by this dataset to be unpinned: %v", err)
+	}
+}
+
+func TestPinChecksums(t *testing.T) {
+	client, d, hash := exportTestVersion(t)
+
+	sidecar, err := d.recordChecksums(hash, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := d.Manifest.Versions[0]
+	version.Checksums = sidecar
+
+	pinned := func() map[string]bool {
+		t.Helper()
+		set, err := client.pinnedSet(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return set
+	}
+
+	if _, err := d.Pin(hash, ""); err != nil {
+		t.Fatal(err)
+	} else if set := pinned(); !set[hash] || !set[sidecar] {
+		t.Fatalf("expected the version and its checksums to be pinned, got %v", set)
+	}
+
+	if _, err := d.Unpin(hash); err != nil {
+		t.Fatal(err)
+	} else if set := pinned(); set[hash] || set[sidecar] {
+		t.Fatalf("expected the version and its checksums to be unpinned, got %v", set)
+	}
+
+	if _, err := d.Pin(hash, ""); err != nil {
+		t.Fatal(err)
+	}
+	d.Config.Retention = &RetentionConfig{}
+	if result, err := d.GC(false, false); err != nil {
+		t.Fatal(err)
+	} else if len(result.Unpinned) != 1 {
+		t.Fatalf("expected the version to be unpinned, got %+v", result)
+	} else if set := pinned(); set[hash] || set[sidecar] {
+		t.Errorf("expected gc to unpin the checksums with the version, got %v", set)
+	}
+	if _, err := client.GetChecksums(d, sidecar); err == nil {
+		t.Errorf("expected the checksums to be collected")
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrQuotaExceeded = errors.New("dataset quota exceeded")
+
+var byteUnits = []struct {
+	suffix string
+	size   uint64
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"KB", 1e3},
+	{"MB", 1e6},
+	{"GB", 1e9},
+	{"TB", 1e12},
+	{"B", 1},
+}
+
+// ParseBytes parses a size such as "1024", "500MB" or "1.5GiB".
+func ParseBytes(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	for _, unit := range byteUnits {
+		if n, ok := strings.CutSuffix(s, unit.suffix); ok {
+			count, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid size %q", s)
+			}
+			return uint64(count * float64(unit.size)), nil
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n, nil
+}
+
+// RecordedSize returns the total size of the versions of the manifest as
+// recorded when they were committed, and the versions which have no recorded
+// size.
+func (manifest *Manifest) RecordedSize() (uint64, []*Version) {
+	var total uint64
+	var unrecorded []*Version
+	for _, version := range manifest.Versions {
+		if version.Summary == nil {
+			unrecorded = append(unrecorded, version)
+		} else {
+			total += version.Summary.Bytes
+		}
+	}
+	return total, unrecorded
+}
+
+// CheckSummaries walks each version which new adds to old and returns an
+// error if the size recorded with it is not the size of its content, so that
+// a recorded size can be trusted once it has been received.
+func (d *Dorothy) CheckSummaries(old, new *Manifest) error {
+	delta, err := old.Diff(new)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range delta {
+		if version.Summary == nil {
+			continue
+		}
+		summary, err := d.Ipfs.Summarize(d, version.Hash)
+		if err != nil {
+			return fmt.Errorf("cannot determine the size of %s: %v", version.Hash, err)
+		} else if summary != *version.Summary {
+			return fmt.Errorf("version %s records %s but contains %s", version.Hash, version.Summary, summary)
+		}
+	}
+	return nil
+}
+
+// CheckQuota returns ErrQuotaExceeded if the versions of manifest are larger
+// than the dataset quota. Only versions committed before sizes were recorded
+// need to be walked, and each of those only once.
+func (d *Dorothy) CheckQuota(manifest *Manifest) error {
+	if d.Config.Quota == nil || d.Config.Quota.Dataset == "" {
+		return nil
+	}
+
+	limit, err := ParseBytes(d.Config.Quota.Dataset)
+	if err != nil {
+		return fmt.Errorf("invalid dataset quota: %v", err)
+	}
+
+	total, unrecorded := manifest.RecordedSize()
+	for _, version := range unrecorded {
+		size, err := d.legacySize(version.Hash)
+		if err != nil {
+			return err
+		}
+		total += size
+	}
+
+	if total > limit {
+		return fmt.Errorf("%w: %s is more than the %s allowed", ErrQuotaExceeded, FormatBytes(total), FormatBytes(limit))
+	}
+	return nil
+}
+
+func (d *Dorothy) legacySize(hash string) (uint64, error) {
+	if size, ok := d.legacySizes.Load(hash); ok {
+		return size.(uint64), nil
+	}
+	summary, err := d.Ipfs.Summarize(d, hash)
+	if err != nil {
+		return 0, fmt.Errorf("cannot determine the size of %s: %v", hash, err)
+	}
+	d.legacySizes.Store(hash, summary.Bytes)
+	return summary.Bytes, nil
+}
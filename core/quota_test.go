@@ -0,0 +1,144 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/kubo/core/coreiface/options"
+)
+
+func TestParseBytes(t *testing.T) {
+	cases := map[string]uint64{
+		"1024":    1024,
+		"10B":     10,
+		"500MB":   500 * 1000 * 1000,
+		"1.5GiB":  3 << 29,
+		" 2 KiB ": 2048,
+	}
+	for s, expected := range cases {
+		if n, err := ParseBytes(s); err != nil {
+			t.Errorf("%q: %v", s, err)
+		} else if n != expected {
+			t.Errorf("%q: expected %d, got %d", s, expected, n)
+		}
+	}
+
+	for _, s := range []string{"lots", "-1MB", "10XB"} {
+		if _, err := ParseBytes(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	_, d, hash := exportTestVersion(t)
+
+	// One version has a recorded size and the other must be walked.
+	manifest := &Manifest{Versions: []*Version{
+		{Hash: hash, PathType: PathTypeDirectory},
+		{Hash: "recorded", Summary: &TreeSummary{Files: 1, Bytes: 100}},
+	}}
+	if total, unrecorded := manifest.RecordedSize(); total != 100 || len(unrecorded) != 1 || unrecorded[0].Hash != hash {
+		t.Fatalf("unexpected recorded size %d of %v", total, unrecorded)
+	}
+
+	if err := d.CheckQuota(manifest); err != nil {
+		t.Errorf("expected no quota to allow anything, got %v", err)
+	}
+
+	d.Config.Quota = &QuotaConfig{Dataset: "103B"}
+	if err := d.CheckQuota(manifest); err != nil {
+		t.Errorf("expected 103 bytes to fit, got %v", err)
+	}
+
+	if _, ok := d.legacySizes.Load(hash); !ok {
+		t.Errorf("expected the size of the unrecorded version to be cached")
+	}
+
+	d.Config.Quota.Dataset = "102B"
+	if err := d.CheckQuota(manifest); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected the quota to be exceeded, got %v", err)
+	}
+
+	d.Config.Quota.Dataset = "plenty"
+	if err := d.CheckQuota(manifest); err == nil || errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected an invalid quota to be reported, got %v", err)
+	}
+}
+
+func TestCheckSummaries(t *testing.T) {
+	_, d, hash := exportTestVersion(t)
+
+	summary, err := d.Ipfs.Summarize(d, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := &Manifest{}
+	honest := &Manifest{Versions: []*Version{{Hash: hash, Summary: &summary}}}
+	if err := d.CheckSummaries(old, honest); err != nil {
+		t.Errorf("expected a correct size to be accepted, got %v", err)
+	}
+
+	unrecorded := &Manifest{Versions: []*Version{{Hash: hash}}}
+	if err := d.CheckSummaries(old, unrecorded); err != nil {
+		t.Errorf("expected a version without a size to be accepted, got %v", err)
+	}
+
+	lie := summary
+	lie.Bytes = 0
+	dishonest := &Manifest{Versions: []*Version{{Hash: hash, Summary: &lie}}}
+	if err := d.CheckSummaries(old, dishonest); err == nil {
+		t.Errorf("expected a false size to be rejected")
+	}
+
+	// Versions the receiver already has are not walked again.
+	if err := d.CheckSummaries(dishonest, dishonest); err != nil {
+		t.Errorf("expected known versions to be skipped, got %v", err)
+	}
+}
+
+func TestRecieveChecksSummaries(t *testing.T) {
+	client, d, _ := exportTestVersion(t)
+
+	filename := filepath.Join(t.TempDir(), "pushed.txt")
+	if err := os.WriteFile(filename, []byte("pushed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := client.Add(d, filename, options.Unixfs.Pin(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	push := func(summary TreeSummary) (*Manifest, error) {
+		manifest, err := client.SaveManifest(d, &Manifest{Versions: []*Version{
+			{Hash: hash, PathType: PathTypeFile, Summary: &summary},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		received, _, err := d.Recieve(&Manifest{}, manifest.Hash)
+		return received, err
+	}
+	pinned := func() bool {
+		set, err := client.pinnedSet(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return set[hash]
+	}
+
+	if _, err := push(TreeSummary{Files: 1}); err == nil {
+		t.Errorf("expected a false size to be rejected")
+	} else if pinned() {
+		t.Errorf("expected the rejected version not to stay pinned")
+	}
+
+	if received, err := push(TreeSummary{Files: 1, Bytes: 6}); err != nil {
+		t.Errorf("expected a correct size to be accepted, got %v", err)
+	} else if len(received.Versions) != 1 || !pinned() {
+		t.Errorf("expected the version to be received and pinned, got %+v", received)
+	}
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	ReflogInit   = "init"
+	ReflogCommit = "commit"
+	ReflogFetch  = "fetch"
+	ReflogImport = "import"
+	ReflogPush   = "push"
+	ReflogReset  = "reset"
+	ReflogUndo   = "undo"
+)
+
+// ReflogEntry records one change of the manifest pointer.
+type ReflogEntry struct {
+	Old       string    `json:"old,omitempty"`
+	New       string    `json:"new"`
+	Date      time.Time `json:"date"`
+	Operation string    `json:"operation"`
+	Message   string    `json:"message,omitempty"`
+}
+
+func (d *Dorothy) ReflogPath() string {
+	return filepath.Join(d.Directory, "logs", "manifest")
+}
+
+func (d *Dorothy) appendReflog(entry ReflogEntry) error {
+	if err := os.MkdirAll(filepath.Dir(d.ReflogPath()), 0755); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	handle, err := os.OpenFile(d.ReflogPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	if _, err := handle.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return handle.Sync()
+}
+
+// Reflog returns the history of the manifest pointer, oldest first.
+func (d *Dorothy) Reflog() ([]ReflogEntry, error) {
+	handle, err := os.Open(d.ReflogPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+
+	var entries []ReflogEntry
+	scanner := bufio.NewScanner(handle)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry ReflogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", d.ReflogPath(), lineno, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// ResolveManifest expands a prefix of a manifest hash which appears in the
+// reflog. Hashes which are not in the reflog are returned unchanged.
+func (d *Dorothy) ResolveManifest(prefix string) (string, error) {
+	entries, err := d.Reflog()
+	if err != nil {
+		return "", err
+	}
+
+	matches := make(map[string]bool)
+	for _, entry := range entries {
+		for _, hash := range []string{entry.Old, entry.New} {
+			if hash == prefix {
+				return hash, nil
+			} else if hash != "" && strings.HasPrefix(hash, prefix) {
+				matches[hash] = true
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return prefix, nil
+	case 1:
+		for hash := range matches {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf("manifest hash %q is ambiguous", prefix)
+}
+
+// ResetManifest points the repository at an earlier manifest. The manifest
+// must be retrievable, so that a typo cannot leave the repository unusable.
+func (d *Dorothy) ResetManifest(hash, operation, message string) error {
+	if !d.Ipfs.IsConnected() {
+		return fmt.Errorf("not connected to IPFS")
+	}
+
+	hash, err := d.ResolveManifest(hash)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := d.Ipfs.GetManifest(d, hash)
+	if err != nil {
+		return fmt.Errorf("cannot load manifest %q: %v", hash, err)
+	}
+
+	lock, err := d.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	d.Manifest = manifest
+	return d.WriteManifestFile(operation, message)
+}
+
+// Undo restores the manifest pointer to its value before the most recent
+// change. Undoing twice in a row therefore returns to where you started.
+func (d *Dorothy) Undo() (ReflogEntry, error) {
+	entries, err := d.Reflog()
+	if err != nil {
+		return ReflogEntry{}, err
+	} else if len(entries) == 0 || entries[len(entries)-1].Old == "" {
+		return ReflogEntry{}, fmt.Errorf("nothing to undo")
+	}
+
+	last := entries[len(entries)-1]
+	message := last.Operation
+	if summary, _, _ := strings.Cut(last.Message, "\n"); summary != "" {
+		message += ": " + summary
+	}
+	return last, d.ResetManifest(last.Old, ReflogUndo, message)
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReflog(t *testing.T) {
+	client, ctx := setup(t)
+
+	empty, err := client.CreateEmptyManifest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Dorothy{Context: ctx, Directory: t.TempDir(), Ipfs: *client, Manifest: empty}
+	if err := d.WriteManifestFile(ReflogInit, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Undo(); err == nil {
+		t.Errorf("expected nothing to undo after init")
+	}
+
+	version := &Version{Author: "X <x@y>", Date: time.Now(), Message: "first\n\ndetails", Hash: empty.Hash, PathType: PathTypeFile}
+	if _, err := d.MergeManifest(&Manifest{Versions: []*Version{version}}, ReflogCommit, version.Message); err != nil {
+		t.Fatal(err)
+	}
+	committed := d.Manifest.Hash
+
+	entries, err := d.Reflog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 reflog entries, got %d", len(entries))
+	}
+	if entries[1].Old != empty.Hash || entries[1].New != committed || entries[1].Operation != ReflogCommit {
+		t.Errorf("unexpected entry %+v", entries[1])
+	}
+
+	undone, err := d.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undone.New != committed {
+		t.Errorf("expected to undo the commit, undid %+v", undone)
+	}
+	if hash, _ := os.ReadFile(d.ManifestPath()); string(hash) != empty.Hash || len(d.Manifest.Versions) != 0 {
+		t.Errorf("expected the manifest to be restored to %q, got %q", empty.Hash, hash)
+	}
+
+	if err := d.ResetManifest(committed[:10], ReflogReset, ""); err != nil {
+		t.Fatal(err)
+	}
+	if d.Manifest.Hash != committed {
+		t.Errorf("expected reset to %q, got %q", committed, d.Manifest.Hash)
+	}
+
+	entries, err = d.Reflog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := entries[len(entries)-2]
+	if last.Operation != ReflogUndo || last.Message != "commit: first" {
+		t.Errorf("unexpected undo entry %+v", last)
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/adrg/xdg"
+)
+
+const registryLockTimeout = 30 * time.Second
+
+// Registry maps the .dorothy directory of each dataset which uses an IPFS
+// store other than its own, either the shared repository or a global
+// instance, to the store it uses. Pins in such a store belong to every
+// dataset using it, so pinning and garbage collection consult the registry
+// before discarding anything.
+type Registry map[string]string
+
+func RegistryPath() string {
+	return filepath.Join(xdg.DataHome, "dorothy", "datasets.toml")
+}
+
+func LoadRegistry() (Registry, error) {
+	registry := make(Registry)
+	if _, err := toml.DecodeFile(RegistryPath(), &registry); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Store names the IPFS store that the node uses, or is empty if the store
+// belongs to a single dataset.
+func (s *Ipfs) Store() string {
+	if s.config.Global {
+		return "global:" + s.config.Url()
+	} else if s.config.Shared {
+		return "shared:" + SharedIpfsPath()
+	}
+	return ""
+}
+
+func (s *Ipfs) IsShared() bool {
+	return s.Store() != ""
+}
+
+// register records the dataset in the registry if it uses a shared store.
+func (d *Dorothy) register() error {
+	store := d.Ipfs.Store()
+	if store == "" {
+		return nil
+	}
+
+	if registry, err := LoadRegistry(); err == nil && registry[d.Directory] == store {
+		return nil
+	}
+
+	dir := filepath.Dir(RegistryPath())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(d, registryLockTimeout)
+	defer cancel()
+	unlock, err := waitForLock(ctx, dir, "datasets.lock")
+	if err != nil {
+		return err
+	}
+	defer unlock.Close()
+
+	registry, err := LoadRegistry()
+	if err != nil {
+		return err
+	}
+	registry[d.Directory] = store
+
+	buffer := new(bytes.Buffer)
+	if err := toml.NewEncoder(buffer).Encode(registry); err != nil {
+		return err
+	}
+	return writeFileAtomic(RegistryPath(), buffer.Bytes(), 0644)
+}
+
+// otherDatasets lists the other datasets which still exist and use the same
+// store as this one.
+func (d *Dorothy) otherDatasets() ([]string, error) {
+	store := d.Ipfs.Store()
+	if store == "" {
+		return nil, nil
+	}
+
+	registry, err := LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	var others []string
+	for dir, used := range registry {
+		other := &Dorothy{Directory: dir}
+		if used == store && dir != d.Directory && other.IsInitialized() {
+			others = append(others, dir)
+		}
+	}
+	sort.Strings(others)
+	return others, nil
+}
+
+// referencedElsewhere returns the hashes of the versions, and of their
+// checksums, which other datasets using the same store refer to.
+func (d *Dorothy) referencedElsewhere() (map[string]bool, error) {
+	others, err := d.otherDatasets()
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+	for _, dir := range others {
+		other := &Dorothy{Context: d, Directory: dir, Ipfs: d.Ipfs}
+		if err := other.LoadManifest(); err != nil {
+			return nil, fmt.Errorf("cannot read the manifest of %s: %v", filepath.Dir(dir), err)
+		}
+		for _, version := range other.Manifest.Versions {
+			referenced[version.Hash] = true
+			if version.Checksums != "" {
+				referenced[version.Checksums] = true
+			}
+		}
+	}
+	return referenced, nil
+}
@@ -0,0 +1,355 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Statuses of a request to a pinning service, as defined by the IPFS Pinning
+// Service API.
+const (
+	RemotePinQueued  = "queued"
+	RemotePinPinning = "pinning"
+	RemotePinPinned  = "pinned"
+	RemotePinFailed  = "failed"
+)
+
+// RemotePin records a request to pin a version to a pinning service.
+type RemotePin struct {
+	Service   string    `toml:"service"`
+	Hash      string    `toml:"hash"`
+	RequestId string    `toml:"request_id"`
+	Status    string    `toml:"status"`
+	Created   time.Time `toml:"created"`
+	Updated   time.Time `toml:"updated"`
+}
+
+func (p RemotePin) IsDone() bool {
+	return p.Status == RemotePinPinned || p.Status == RemotePinFailed
+}
+
+type remotePins struct {
+	Pins []RemotePin `toml:"pin"`
+}
+
+// pinningClient speaks the IPFS Pinning Service API.
+type pinningClient struct {
+	*http.Client
+	endpoint *url.URL
+	token    string
+}
+
+type pinningStatus struct {
+	RequestId string    `json:"requestid"`
+	Status    string    `json:"status"`
+	Created   time.Time `json:"created"`
+	Pin       struct {
+		Cid  string `json:"cid"`
+		Name string `json:"name,omitempty"`
+	} `json:"pin"`
+}
+
+type pinningRequest struct {
+	Cid     string   `json:"cid"`
+	Name    string   `json:"name,omitempty"`
+	Origins []string `json:"origins,omitempty"`
+}
+
+type pinningFailure struct {
+	Error struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	} `json:"error"`
+}
+
+func newPinningClient(service *PinningServiceConfig) (*pinningClient, error) {
+	endpoint, err := url.Parse(service.Endpoint)
+	if err != nil {
+		return nil, err
+	} else if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("pinning service endpoint %q does not have http(s) scheme", service.Endpoint)
+	}
+	return &pinningClient{
+		Client:   &http.Client{Timeout: time.Minute},
+		endpoint: endpoint,
+		token:    service.Token,
+	}, nil
+}
+
+func (c *pinningClient) do(ctx context.Context, method string, body any, elem ...string) (*pinningStatus, error) {
+	var r io.Reader
+	if body != nil {
+		buffer := new(bytes.Buffer)
+		if err := json.NewEncoder(buffer).Encode(body); err != nil {
+			return nil, err
+		}
+		r = buffer
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint.JoinPath(elem...).String(), r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var failure pinningFailure
+		if err := json.NewDecoder(res.Body).Decode(&failure); err == nil && failure.Error.Reason != "" {
+			if failure.Error.Details != "" {
+				return nil, fmt.Errorf("%s: %s", failure.Error.Reason, failure.Error.Details)
+			}
+			return nil, errors.New(failure.Error.Reason)
+		}
+		return nil, fmt.Errorf("unexpected status from pinning service: %s", res.Status)
+	}
+
+	var status pinningStatus
+	if err := json.NewDecoder(res.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (c *pinningClient) Add(ctx context.Context, request pinningRequest) (*pinningStatus, error) {
+	return c.do(ctx, http.MethodPost, request, "pins")
+}
+
+func (c *pinningClient) Get(ctx context.Context, requestId string) (*pinningStatus, error) {
+	return c.do(ctx, http.MethodGet, nil, "pins", requestId)
+}
+
+func (d *Dorothy) RemotePinsPath() string {
+	return filepath.Join(d.Directory, "remotepins.toml")
+}
+
+func (d *Dorothy) LoadRemotePins() ([]RemotePin, error) {
+	var pins remotePins
+	if _, err := toml.DecodeFile(d.RemotePinsPath(), &pins); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return pins.Pins, nil
+}
+
+// updateRemotePins applies update to the records of pin requests while
+// holding the repository lock.
+func (d *Dorothy) updateRemotePins(update func([]RemotePin) []RemotePin) error {
+	lock, err := d.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	pins, err := d.LoadRemotePins()
+	if err != nil {
+		return err
+	}
+	pins = update(pins)
+
+	buffer := new(bytes.Buffer)
+	if err := toml.NewEncoder(buffer).Encode(remotePins{Pins: pins}); err != nil {
+		return err
+	}
+	return writeFileAtomic(d.RemotePinsPath(), buffer.Bytes(), 0644)
+}
+
+func (d *Dorothy) recordRemotePin(pin RemotePin) error {
+	return d.updateRemotePins(func(pins []RemotePin) []RemotePin {
+		for i := range pins {
+			if pins[i].Service == pin.Service && pins[i].RequestId == pin.RequestId {
+				pins[i] = pin
+				return pins
+			}
+		}
+		return append(pins, pin)
+	})
+}
+
+func (d *Dorothy) pinningService(name string) (*PinningServiceConfig, error) {
+	service, ok := d.Config.Pinning[name]
+	if !ok || service == nil {
+		return nil, fmt.Errorf("no pinning service named %q; see `dorothy config set pinning.%s.endpoint`", name, name)
+	} else if service.Endpoint == "" {
+		return nil, fmt.Errorf("pinning service %q has no endpoint", name)
+	}
+	return service, nil
+}
+
+// origins lists the addresses at which the pinning service can fetch content
+// from this node. It is best effort; the service can also find providers.
+func (d *Dorothy) origins() []string {
+	if d.Ipfs.Identity == "" {
+		return nil
+	}
+	addrs, err := d.Ipfs.Swarm().LocalAddrs(d)
+	if err != nil {
+		return nil
+	}
+
+	var origins []string
+	for _, addr := range addrs {
+		origins = append(origins, fmt.Sprintf("%s/p2p/%s", addr, d.Ipfs.Identity))
+	}
+	return origins
+}
+
+func (d *Dorothy) pinRemote(name string, service *PinningServiceConfig, version *Version) (RemotePin, error) {
+	client, err := newPinningClient(service)
+	if err != nil {
+		return RemotePin{}, err
+	}
+
+	status, err := client.Add(d, pinningRequest{
+		Cid:     version.Hash,
+		Name:    version.Hash,
+		Origins: d.origins(),
+	})
+	if err != nil {
+		return RemotePin{}, fmt.Errorf("cannot pin %s to %q: %v", version.Hash, name, err)
+	}
+
+	pin := RemotePin{
+		Service:   name,
+		Hash:      version.Hash,
+		RequestId: status.RequestId,
+		Status:    status.Status,
+		Created:   status.Created,
+		Updated:   time.Now(),
+	}
+	return pin, d.recordRemotePin(pin)
+}
+
+// PinRemote asks the named pinning service to pin a version.
+func (d *Dorothy) PinRemote(rev, name string) (RemotePin, error) {
+	if d.Manifest == nil {
+		return RemotePin{}, fmt.Errorf("no manifest found")
+	}
+
+	service, err := d.pinningService(name)
+	if err != nil {
+		return RemotePin{}, err
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return RemotePin{}, err
+	}
+
+	return d.pinRemote(name, service, version)
+}
+
+// PinPushedVersions asks every pinning service which is not configured with
+// nopush to pin the versions that it has not already been asked to pin.
+func (d *Dorothy) PinPushedVersions() ([]RemotePin, error) {
+	if d.Manifest == nil {
+		return nil, fmt.Errorf("no manifest found")
+	}
+
+	existing, err := d.LoadRemotePins()
+	if err != nil {
+		return nil, err
+	}
+	requested := make(map[string]bool)
+	for _, pin := range existing {
+		if pin.Status != RemotePinFailed {
+			requested[pin.Service+"/"+pin.Hash] = true
+		}
+	}
+
+	var names []string
+	for name, service := range d.Config.Pinning {
+		if service != nil && !service.NoPush {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var pins []RemotePin
+	var errs []error
+	for _, name := range names {
+		service, err := d.pinningService(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, version := range d.Manifest.Versions {
+			if requested[name+"/"+version.Hash] {
+				continue
+			}
+			pin, err := d.pinRemote(name, service, version)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			pins = append(pins, pin)
+		}
+	}
+
+	return pins, errors.Join(errs...)
+}
+
+// RemotePinStatus returns the recorded pin requests, first asking the
+// services for the current status of those which are not yet done.
+func (d *Dorothy) RemotePinStatus(refresh bool) ([]RemotePin, error) {
+	pins, err := d.LoadRemotePins()
+	if err != nil || !refresh {
+		return pins, err
+	}
+
+	var errs []error
+	for i, pin := range pins {
+		if pin.IsDone() {
+			continue
+		}
+
+		service, err := d.pinningService(pin.Service)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		client, err := newPinningClient(service)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		status, err := client.Get(d, pin.RequestId)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot get status of %s from %q: %v", pin.Hash, pin.Service, err))
+			continue
+		}
+
+		pins[i].Status = strings.ToLower(status.Status)
+		pins[i].Updated = time.Now()
+		if err := d.recordRemotePin(pins[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return pins, errors.Join(errs...)
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockPinningService implements enough of the IPFS Pinning Service API to
+// test against. Requests are queued when made and pinned once queried.
+type mockPinningService struct {
+	sync.Mutex
+	token    string
+	requests map[string]*pinningStatus
+}
+
+func (m *mockPinningService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Header.Get("Authorization") != "Bearer "+m.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"reason": "UNAUTHORIZED", "details": "bad token"}}`)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/pins":
+		var request pinningRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		status := &pinningStatus{
+			RequestId: fmt.Sprintf("request-%d", len(m.requests)),
+			Status:    RemotePinQueued,
+			Created:   time.Now(),
+		}
+		status.Pin.Cid = request.Cid
+		status.Pin.Name = request.Name
+		m.requests[status.RequestId] = status
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(status)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/pins/"):
+		status, ok := m.requests[strings.TrimPrefix(r.URL.Path, "/pins/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"reason": "NOT_FOUND"}}`)
+			return
+		}
+		status.Status = RemotePinPinned
+		json.NewEncoder(w).Encode(status)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestRemotePinning(t *testing.T) {
+	service := &mockPinningService{token: "secret", requests: make(map[string]*pinningStatus)}
+	server := httptest.NewServer(service)
+	defer server.Close()
+
+	client, ctx := setup(t)
+	hash := func(s string) string {
+		filename := filepath.Join(t.TempDir(), s)
+		if err := os.WriteFile(filename, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+		h, err := client.Add(ctx, filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	first, second := hash("first"), hash("second")
+
+	d := &Dorothy{
+		Context:   ctx,
+		Directory: t.TempDir(),
+		Ipfs:      *client,
+		Manifest: &Manifest{Versions: []*Version{
+			{Date: time.Now(), Hash: first, PathType: PathTypeFile},
+			{Date: time.Now(), Hash: second, PathType: PathTypeFile, Parents: []string{first}},
+		}},
+		Config: Config{Pinning: map[string]*PinningServiceConfig{
+			"archive": {Endpoint: server.URL, Token: "secret"},
+			"manual":  {Endpoint: server.URL, Token: "secret", NoPush: true},
+		}},
+	}
+
+	pins, err := d.PinPushedVersions()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(pins) != 2 || pins[0].Service != "archive" || pins[0].Status != RemotePinQueued {
+		t.Fatalf("expected both versions to be pinned to the archive, got %+v", pins)
+	}
+
+	if pins, err := d.PinPushedVersions(); err != nil || len(pins) != 0 {
+		t.Errorf("expected versions to be pinned only once, got %+v, %v", pins, err)
+	}
+
+	pin, err := d.PinRemote(first[:8], "manual")
+	if err != nil {
+		t.Fatal(err)
+	} else if pin.Hash != first || pin.Service != "manual" {
+		t.Errorf("unexpected pin %+v", pin)
+	}
+
+	if _, err := d.PinRemote(first, "missing"); err == nil {
+		t.Errorf("expected an error for an unknown service")
+	}
+
+	d.Config.Pinning["manual"].Token = "wrong"
+	if _, err := d.PinRemote(first, "manual"); err == nil || !strings.Contains(err.Error(), "UNAUTHORIZED") {
+		t.Errorf("expected the service to reject a bad token, got %v", err)
+	}
+	d.Config.Pinning["manual"].Token = "secret"
+
+	recorded, err := d.RemotePinStatus(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pin := range recorded {
+		if pin.Status != RemotePinQueued {
+			t.Errorf("expected %+v to be queued without a refresh", pin)
+		}
+	}
+
+	refreshed, err := d.RemotePinStatus(true)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(refreshed) != 3 {
+		t.Fatalf("expected 3 pin requests, got %+v", refreshed)
+	}
+
+	recorded, err = d.LoadRemotePins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pin := range recorded {
+		if pin.Status != RemotePinPinned {
+			t.Errorf("expected %+v to be pinned after a refresh", pin)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/boxo/files"
+	"github.com/ipfs/go-cid"
+
+	ipath "github.com/ipfs/boxo/path"
+	icore "github.com/ipfs/kubo/core/coreiface"
+	"github.com/ipfs/kubo/core/coreiface/options"
+)
+
+type TreeEntry struct {
+	Path string `json:"path"`
+	Cid  string `json:"cid"`
+	Type string `json:"type"`
+	Size uint64 `json:"size"`
+}
+
+func (e TreeEntry) IsDir() bool {
+	return e.Type == icore.TDirectory.String()
+}
+
+func (e TreeEntry) Depth() int {
+	return strings.Count(e.Path, "/")
+}
+
+type TreeSummary struct {
+	Files       int    `json:"files"`
+	Directories int    `json:"directories"`
+	Bytes       uint64 `json:"bytes"`
+}
+
+func (s TreeSummary) String() string {
+	return fmt.Sprintf("%s in %d file(s), %d dir(s)", FormatBytes(s.Bytes), s.Files, s.Directories)
+}
+
+type DiffEntry struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+}
+
+func versionPath(hash, subpath string) (ipath.Path, error) {
+	p := "/ipfs/" + hash
+	if subpath = strings.Trim(path.Clean("/"+subpath), "/"); subpath != "" {
+		p += "/" + subpath
+	}
+	return ipath.NewPath(p)
+}
+
+func (s *Ipfs) Stat(ctx context.Context, hash, subpath string) (TreeEntry, error) {
+	p, err := versionPath(hash, subpath)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+
+	resolved, _, err := s.ResolvePath(ctx, p)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+
+	node, err := s.Unixfs().Get(ctx, resolved)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	defer node.Close()
+
+	entry := TreeEntry{
+		Path: strings.Trim(path.Clean("/"+subpath), "/"),
+		Cid:  resolved.RootCid().String(),
+	}
+
+	switch n := node.(type) {
+	case files.Directory:
+		entry.Type = icore.TDirectory.String()
+	case *files.Symlink:
+		entry.Type = icore.TSymlink.String()
+		entry.Size = uint64(len(n.Target))
+	case files.File:
+		entry.Type = icore.TFile.String()
+		size, err := n.Size()
+		if err != nil {
+			return TreeEntry{}, err
+		}
+		entry.Size = uint64(size)
+	default:
+		entry.Type = icore.TUnknown.String()
+	}
+
+	return entry, nil
+}
+
+func (s *Ipfs) Open(ctx context.Context, hash, subpath string) (io.ReadSeekCloser, error) {
+	p, err := versionPath(hash, subpath)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := s.Unixfs().Get(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	file, ok := node.(files.File)
+	if !ok {
+		node.Close()
+		return nil, fmt.Errorf("%q is not a file", strings.Trim(path.Clean("/"+subpath), "/"))
+	}
+
+	return file, nil
+}
+
+func (s *Ipfs) Ls(ctx context.Context, hash, subpath string, recursive bool) ([]TreeEntry, error) {
+	root, err := s.Stat(ctx, hash, subpath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !root.IsDir() {
+		root.Path = path.Base("/" + root.Path)
+		if root.Path == "/" {
+			root.Path = hash
+		}
+		return []TreeEntry{root}, nil
+	}
+
+	var entries []TreeEntry
+	err = s.walk(ctx, ipath.FromCid(cid.MustParse(root.Cid)), "", recursive, func(entry TreeEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+func (s *Ipfs) walk(ctx context.Context, p ipath.Path, prefix string, recursive bool, fn func(TreeEntry) error) error {
+	ch, err := s.Unixfs().Ls(ctx, p, options.Unixfs.ResolveChildren(true))
+	if err != nil {
+		return err
+	}
+
+	var dirents []icore.DirEntry
+	for dirent := range ch {
+		if dirent.Err != nil {
+			return dirent.Err
+		}
+		dirents = append(dirents, dirent)
+	}
+	sort.Slice(dirents, func(i, j int) bool {
+		return dirents[i].Name < dirents[j].Name
+	})
+
+	for _, dirent := range dirents {
+		entry := TreeEntry{
+			Path: path.Join(prefix, dirent.Name),
+			Cid:  dirent.Cid.String(),
+			Type: dirent.Type.String(),
+			Size: dirent.Size,
+		}
+		if err := fn(entry); err == fs.SkipDir {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if recursive && dirent.Type == icore.TDirectory {
+			if err := s.walk(ctx, ipath.FromCid(dirent.Cid), entry.Path, recursive, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Ipfs) Summarize(ctx context.Context, hash string) (TreeSummary, error) {
+	entries, err := s.Ls(ctx, hash, "", true)
+	if err != nil {
+		return TreeSummary{}, err
+	}
+
+	var summary TreeSummary
+	for _, entry := range entries {
+		if entry.IsDir() {
+			summary.Directories++
+		} else {
+			summary.Files++
+			summary.Bytes += entry.Size
+		}
+	}
+	return summary, nil
+}
+
+// VersionSummary returns the summary recorded with a version, walking the
+// version only if none was.
+func (s *Ipfs) VersionSummary(ctx context.Context, version *Version) (TreeSummary, error) {
+	if version.Summary != nil {
+		return *version.Summary, nil
+	}
+	return s.Summarize(ctx, version.Hash)
+}
+
+func (s *Ipfs) Diff(ctx context.Context, from, to string) ([]DiffEntry, error) {
+	fromRoot, err := s.Stat(ctx, from, "")
+	if err != nil {
+		return nil, err
+	}
+	toRoot, err := s.Stat(ctx, to, "")
+	if err != nil {
+		return nil, err
+	}
+	if !fromRoot.IsDir() || !toRoot.IsDir() {
+		if fromRoot.Cid == toRoot.Cid {
+			return nil, nil
+		}
+		return []DiffEntry{{Type: "modified", Path: ""}}, nil
+	}
+
+	fromPath, err := versionPath(from, "")
+	if err != nil {
+		return nil, err
+	}
+	toPath, err := versionPath(to, "")
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.Object().Diff(ctx, fromPath, toPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DiffEntry
+	for _, change := range changes {
+		entry := DiffEntry{Path: change.Path}
+		switch change.Type {
+		case icore.DiffAdd:
+			entry.Type = "added"
+		case icore.DiffRemove:
+			entry.Type = "removed"
+		case icore.DiffMod:
+			entry.Type = "modified"
+		default:
+			return nil, fmt.Errorf("unexpected change type %d", change.Type)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func addTree(t *testing.T, client *Ipfs, ctx context.Context, contents map[string]string) string {
+	t.Helper()
+
+	root, err := os.MkdirTemp(os.TempDir(), "dorothy-tree-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(root)
+	})
+
+	for name, content := range contents {
+		filename := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hash, err := client.Add(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestLsRecursive(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"README.md":        "# Data\n",
+		"site-a/data.csv":  "a,b\n1,2\n",
+		"site-b/data.csv":  "a,b\n3,4\n",
+		"site-b/notes.txt": "notes",
+	})
+
+	entries, err := client.Ls(ctx, hash, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	expected := []string{"README.md", "site-a", "site-a/data.csv", "site-b", "site-b/data.csv", "site-b/notes.txt"}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %q, got %q", expected, paths)
+	}
+
+	if entries[0].Size != 7 || entries[0].IsDir() {
+		t.Errorf("expected README.md to be a 7 byte file, got %v", entries[0])
+	}
+	if !entries[1].IsDir() {
+		t.Errorf("expected site-a to be a directory, got %v", entries[1])
+	}
+}
+
+func TestLsSubpath(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"site-a/data.csv":  "a,b\n1,2\n",
+		"site-b/notes.txt": "notes",
+	})
+
+	entries, err := client.Ls(ctx, hash, "site-b", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "notes.txt" {
+		t.Errorf("expected only notes.txt, got %v", entries)
+	}
+
+	entries, err = client.Ls(ctx, hash, "site-a/data.csv", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != "data.csv" || entries[0].Size != 8 {
+		t.Errorf("expected data.csv of 8 bytes, got %v", entries)
+	}
+
+	if _, err := client.Ls(ctx, hash, "site-c", false); err == nil {
+		t.Errorf("expected an error for a missing path")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"README.md":       "# Data\n",
+		"site-a/data.csv": "a,b\n1,2\n",
+	})
+
+	summary, err := client.Summarize(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := TreeSummary{Files: 2, Directories: 1, Bytes: 15}
+	if summary != expected {
+		t.Errorf("expected %v, got %v", expected, summary)
+	}
+
+	if s := summary.String(); s != "15 B in 2 file(s), 1 dir(s)" {
+		t.Errorf("unexpected string %q", s)
+	}
+
+	// A recorded summary is used as is.
+	recorded := TreeSummary{Files: 7}
+	if summary, err := client.VersionSummary(ctx, &Version{Hash: hash, Summary: &recorded}); err != nil {
+		t.Fatal(err)
+	} else if summary != recorded {
+		t.Errorf("expected the recorded summary %v, got %v", recorded, summary)
+	}
+	if summary, err := client.VersionSummary(ctx, &Version{Hash: hash}); err != nil {
+		t.Fatal(err)
+	} else if summary != expected {
+		t.Errorf("expected %v, got %v", expected, summary)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	client, ctx := setup(t)
+
+	from := addTree(t, client, ctx, map[string]string{
+		"README.md": "# Data\n",
+		"old.csv":   "1\n",
+		"same.csv":  "2\n",
+	})
+	to := addTree(t, client, ctx, map[string]string{
+		"README.md": "# Data, revised\n",
+		"new.csv":   "3\n",
+		"same.csv":  "2\n",
+	})
+
+	entries, err := client.Diff(ctx, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"README.md": "modified",
+		"old.csv":   "removed",
+		"new.csv":   "added",
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), entries)
+	}
+	for _, entry := range entries {
+		if expected[entry.Path] != entry.Type {
+			t.Errorf("expected %s to be %q, got %q", entry.Path, expected[entry.Path], entry.Type)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	client, ctx := setup(t)
+
+	hash := addTree(t, client, ctx, map[string]string{
+		"site-a/data.csv": "a,b\n1,2\n3,4\n",
+	})
+
+	file, err := client.Open(ctx, hash, "site-a/data.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(file, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "1,2" {
+		t.Errorf("expected %q, got %q", "1,2", buf)
+	}
+
+	if _, err := client.Open(ctx, hash, "site-a"); err == nil {
+		t.Errorf("expected an error when opening a directory")
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Verify compares the files under dir with those of a version without
+// fetching their content. Each local file is hashed with the settings the
+// version was added with, or ones inferred from it if add is nil, and every
+// file which differs, is missing, or is not part of the version is reported.
+func (s *Ipfs) Verify(ctx context.Context, hash, dir string, add *AddSettings) ([]DiffEntry, error) {
+	root, err := s.Stat(ctx, hash, "")
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := localFiles(dir, NewCheckoutSettings())
+	if err != nil {
+		return nil, err
+	} else if local == nil {
+		return nil, fmt.Errorf("%s does not exist", dir)
+	}
+
+	if info, ok := local["."]; ok {
+		if root.IsDir() {
+			return nil, fmt.Errorf("version %s is a directory but %s is not", hash, dir)
+		}
+		matches, err := s.matchesEntry(ctx, dir, info, root, add)
+		if err != nil {
+			return nil, err
+		} else if !matches {
+			return []DiffEntry{{Type: "modified", Path: filepath.Base(dir)}}, nil
+		}
+		return nil, nil
+	} else if !root.IsDir() {
+		return nil, fmt.Errorf("version %s is a file but %s is a directory", hash, dir)
+	}
+
+	entries, err := s.Ls(ctx, hash, "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff []DiffEntry
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, ok := local[entry.Path]
+		if !ok {
+			diff = append(diff, DiffEntry{Type: "missing", Path: entry.Path})
+			continue
+		}
+		delete(local, entry.Path)
+
+		matches, err := s.matchesEntry(ctx, filepath.Join(dir, filepath.FromSlash(entry.Path)), info, entry, add)
+		if err != nil {
+			return nil, err
+		} else if !matches {
+			diff = append(diff, DiffEntry{Type: "modified", Path: entry.Path})
+		}
+	}
+	for p := range local {
+		diff = append(diff, DiffEntry{Type: "extra", Path: p})
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Path < diff[j].Path
+	})
+	return diff, nil
+}
+
+// Verify compares dir with a version, which defaults to the one dir was last
+// checked out from or committed as.
+func (d *Dorothy) Verify(dir, rev string) (*Version, []DiffEntry, error) {
+	if !d.Ipfs.IsConnected() {
+		return nil, nil, fmt.Errorf("not connected to IPFS")
+	}
+
+	if rev == "" {
+		if rev = d.BaseVersion(dir); rev == "" {
+			return nil, nil, fmt.Errorf("%s was not checked out from a version; specify one", dir)
+		}
+	}
+
+	version, _, err := d.ResolveRevision(rev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diff, err := d.Ipfs.Verify(d, version.Hash, dir, version.Add)
+	return version, diff, err
+}
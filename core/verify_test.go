@@ -0,0 +1,135 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/kubo/core/coreiface/options"
+)
+
+func TestVerify(t *testing.T) {
+	client, ctx := setup(t)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"README.md":       "# Data\n",
+		"site-a/data.csv": "a,b\n1,2\n",
+		"site-b/data.csv": "c,d\n3,4\n",
+	} {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The local files must be hashed with the settings of the version, not
+	// the defaults.
+	hash, err := client.Add(ctx, dir, options.Unixfs.CidVersion(1), options.Unixfs.RawLeaves(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff, err := client.Verify(ctx, hash, dir, nil); err != nil {
+		t.Fatal(err)
+	} else if len(diff) != 0 {
+		t.Fatalf("expected no differences, got %v", diff)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Date\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "site-b", "data.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "site-a", "notes.txt"), []byte("extra"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := client.Verify(ctx, hash, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DiffEntry{
+		{Type: "modified", Path: "README.md"},
+		{Type: "extra", Path: "site-a/notes.txt"},
+		{Type: "missing", Path: "site-b/data.csv"},
+	}
+	if len(diff) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, diff)
+	}
+	for i, entry := range diff {
+		if entry != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], entry)
+		}
+	}
+
+	if _, err := client.Verify(ctx, hash, filepath.Join(dir, "README.md"), nil); err == nil {
+		t.Errorf("expected a file to be rejected for a directory version")
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	client, ctx := setup(t)
+
+	filename := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(filename, []byte("a,b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := client.Add(ctx, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff, err := client.Verify(ctx, hash, filename, nil); err != nil || len(diff) != 0 {
+		t.Fatalf("unexpected result %v, %v", diff, err)
+	}
+
+	if err := os.WriteFile(filename, []byte("a,c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := client.Verify(ctx, hash, filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(diff) != 1 || diff[0] != (DiffEntry{Type: "modified", Path: "data.csv"}) {
+		t.Errorf("expected data.csv to be modified, got %v", diff)
+	}
+}
+
+func TestVerifyAddSettings(t *testing.T) {
+	client, ctx := setup(t)
+
+	filename := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(filename, []byte(strings.Repeat("1,2,3\n", 2000)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither the chunker nor the layout can be recovered from the CID, so
+	// the file only matches when they are recorded.
+	add := &AddSettings{CidVersion: 1, RawLeaves: true, Chunker: "size-1024", Hash: "blake2b-256", Layout: LayoutTrickle}
+	addOptions, err := add.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := client.Add(ctx, filename, addOptions...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff, err := client.Verify(ctx, hash, filename, add); err != nil || len(diff) != 0 {
+		t.Fatalf("expected the file to match with the recorded settings, got %v, %v", diff, err)
+	}
+	if diff, err := client.Verify(ctx, hash, filename, nil); err != nil || len(diff) != 1 {
+		t.Errorf("expected the file not to match with inferred settings, got %v, %v", diff, err)
+	}
+
+	bad := *add
+	bad.Chunker = "nonsense"
+	if _, err := client.Verify(ctx, hash, filename, &bad); err == nil {
+		t.Errorf("expected an invalid chunker to be an error rather than a mismatch")
+	}
+}
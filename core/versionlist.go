@@ -25,16 +25,20 @@ var (
 				Padding(0, 0, 0, 1)
 )
 
+// version is an item of a list of versions. It shows a checkbox if the
+// versions can be chosen.
 type version struct {
-	version *Version
-	chosen  bool
+	version   *Version
+	tags      []string
+	choosable bool
+	chosen    bool
 }
 
 func (v *version) Title() string {
 	s := strings.Builder{}
-	if v.chosen {
+	if v.choosable && v.chosen {
 		s.WriteString("[x] ")
-	} else {
+	} else if v.choosable {
 		s.WriteString("[ ] ")
 	}
 	s.WriteString(v.version.Message)
@@ -42,17 +46,55 @@ func (v *version) Title() string {
 }
 
 func (v *version) Description() string {
-	return v.version.Hash
+	if len(v.tags) == 0 {
+		return v.version.Hash
+	}
+	return v.version.Hash + " (" + strings.Join(v.tags, ", ") + ")"
 }
 
 func (v *version) FilterValue() string {
-	return v.version.Message
+	return strings.Join(append([]string{v.version.Message, v.version.Hash}, v.tags...), " ")
 }
 
 func (v *version) toggle() {
 	v.chosen = !v.chosen
 }
 
+// versionItems lists the versions of the manifest, newest first.
+func versionItems(manifest *Manifest, tags Tags, choosable bool, selected []*Version) []list.Item {
+	var versions []list.Item
+	for _, v := range manifest.ReverseVersions() {
+		chosen := false
+		for _, s := range selected {
+			if v.Equal(s) {
+				chosen = true
+				break
+			}
+		}
+
+		versions = append(versions, &version{
+			version:   v,
+			tags:      tags.For(v.Hash),
+			choosable: choosable,
+			chosen:    chosen,
+		})
+	}
+	return versions
+}
+
+// newVersionList returns a list of versions with the styles shared by every
+// view of the history.
+func newVersionList(title string, versions []list.Item, delegate list.DefaultDelegate) list.Model {
+	delegate.Styles.SelectedTitle = selectedTitleStyle
+	delegate.Styles.SelectedDesc = selectedDescStyle
+
+	versionList := list.New(versions, delegate, 0, 0)
+	versionList.DisableQuitKeybindings()
+	versionList.Title = title
+	versionList.Styles.Title = titleStyle
+	return versionList
+}
+
 type viewModel struct {
 	list     list.Model
 	keys     *listKeyMap
@@ -113,9 +155,6 @@ func newDelegateKeyMap() *delegateKeyMap {
 func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 	d := list.NewDefaultDelegate()
 
-	d.Styles.SelectedTitle = selectedTitleStyle
-	d.Styles.SelectedDesc = selectedDescStyle
-
 	d.UpdateFunc = func(msg tea.Msg, m *list.Model) tea.Cmd {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
@@ -141,35 +180,16 @@ func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 	return d
 }
 
-func newModel(title string, manifest *Manifest, required bool, selected []*Version) viewModel {
+func newModel(title string, manifest *Manifest, tags Tags, required bool, selected []*Version) viewModel {
 	var (
 		listKeys     = newListKeyMap()
 		delegateKeys = newDelegateKeyMap()
 	)
 
-	var versions []list.Item
-	for i := len(manifest.Versions) - 1; i >= 0; i-- {
-		v := manifest.Versions[i]
-
-		chosen := false
-		for _, s := range selected {
-			if v.Equal(s) {
-				chosen = true
-				break
-			}
-		}
-
-		versions = append(versions, &version{
-			version: v,
-			chosen:  chosen,
-		})
-	}
+	versions := versionItems(manifest, tags, true, selected)
 
 	delegate := newItemDelegate(delegateKeys)
-	versionList := list.New(versions, delegate, 0, 0)
-	versionList.DisableQuitKeybindings()
-	versionList.Title = title
-	versionList.Styles.Title = titleStyle
+	versionList := newVersionList(title, versions, delegate)
 	versionList.AdditionalShortHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			listKeys.finish,
@@ -253,7 +273,12 @@ func (d *Dorothy) ChooseVersions(title string, required bool) ([]string, error)
 }
 
 func (d *Dorothy) ChooseVersionsWithSelected(title string, required bool, selected []*Version) ([]string, error) {
-	model := newModel(title, d.Manifest, required, selected)
+	tags, err := d.LoadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	model := newModel(title, d.Manifest, tags, required, selected)
 
 	p := tea.NewProgram(model)
 	m, err := p.Run()
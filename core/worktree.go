@@ -0,0 +1,77 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// WorkingTrees maps directories, relative to the repository root, to the
+// version they were last checked out from or committed as.
+type WorkingTrees map[string]string
+
+func (d *Dorothy) WorkingTreesPath() string {
+	return filepath.Join(d.Directory, "worktrees.toml")
+}
+
+func (d *Dorothy) LoadWorkingTrees() (WorkingTrees, error) {
+	trees := make(WorkingTrees)
+	if _, err := toml.DecodeFile(d.WorkingTreesPath(), &trees); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return trees, nil
+}
+
+func (d *Dorothy) workingTreeKey(dir string) (string, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(filepath.Dir(d.Directory), abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// BaseVersion returns the hash of the version that dir was last checked out
+// from or committed as, or an empty string if dir is not tracked.
+func (d *Dorothy) BaseVersion(dir string) string {
+	key, ok := d.workingTreeKey(dir)
+	if !ok {
+		return ""
+	}
+	trees, err := d.LoadWorkingTrees()
+	if err != nil {
+		return ""
+	}
+	return trees[key]
+}
+
+func (d *Dorothy) SetBaseVersion(dir, hash string) error {
+	key, ok := d.workingTreeKey(dir)
+	if !ok || !d.IsInitialized() {
+		return nil
+	}
+
+	lock, err := d.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	trees, err := d.LoadWorkingTrees()
+	if err != nil {
+		return err
+	}
+	trees[key] = hash
+
+	buffer := new(bytes.Buffer)
+	if err := toml.NewEncoder(buffer).Encode(trees); err != nil {
+		return err
+	}
+	return writeFileAtomic(d.WorkingTreesPath(), buffer.Bytes(), 0644)
+}
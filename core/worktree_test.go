@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseVersion(t *testing.T) {
+	root := t.TempDir()
+	d := &Dorothy{Context: context.Background(), Directory: filepath.Join(root, ".dorothy")}
+	if err := os.Mkdir(d.Directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{d.LocalConfigPath(), d.ManifestPath()} {
+		if err := os.WriteFile(filename, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := filepath.Join(root, "data")
+	if base := d.BaseVersion(data); base != "" {
+		t.Errorf("expected no base version, got %q", base)
+	}
+
+	if err := d.SetBaseVersion(data, "QmA"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetBaseVersion(filepath.Join(root, "other"), "QmB"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetBaseVersion(filepath.Join(t.TempDir(), "outside"), "QmC"); err != nil {
+		t.Fatal(err)
+	}
+
+	if base := d.BaseVersion(data + "/"); base != "QmA" {
+		t.Errorf("expected %q, got %q", "QmA", base)
+	}
+
+	trees, err := d.LoadWorkingTrees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trees) != 2 || trees["data"] != "QmA" || trees["other"] != "QmB" {
+		t.Errorf("unexpected working trees %v", trees)
+	}
+}
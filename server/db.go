@@ -119,6 +119,7 @@ func (s *DatabaseSession) CreateDataset(newdata model.NewDataset, manifest *core
 			Name:           newdata.Name,
 			OrganizationID: newdata.OrganizationID,
 			Contact:        newdata.Contact,
+			License:        newdata.License,
 			IsPrivate:      newdata.IsPrivate,
 			ManifestHash:   manifest.Hash,
 		}
@@ -1,11 +1,15 @@
 package server
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"maps"
 	"time"
 
+	"github.com/39alpha/dorothy/core"
 	"github.com/39alpha/dorothy/sdk"
 	"github.com/39alpha/dorothy/server/model"
 	"github.com/gofiber/fiber/v2"
@@ -439,6 +443,10 @@ func (d *Server) RecieveDataset() fiber.Handler {
 				"conflicts": conflicts,
 				"error":     "merge failed with conflicts",
 			})
+		} else if errors.Is(err, core.ErrQuotaExceeded) {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"error": err.Error(),
+			})
 		} else if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": fmt.Sprintf("merge failed: %v", err),
@@ -489,3 +497,110 @@ func (d *Server) Dataset() fiber.Handler {
 		}), "views/layouts/main")
 	}
 }
+
+func (d *Server) DatasetGraph() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		dataset, ok := c.Locals("Dataset").(*model.Dataset)
+		if !ok || dataset == nil || dataset.Manifest == nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch dataset manifest",
+			})
+		}
+
+		format := core.GraphFormat(c.Query("format", core.GraphFormatSvg.String()))
+		if !format.IsValid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("unsupported graph format %q", format),
+			})
+		}
+
+		// Tags are local to each clone, in its tags.toml, and are not pushed,
+		// so the graph served for a dataset never has tag attributes.
+		c.Set(fiber.HeaderContentType, format.ContentType())
+		return dataset.Manifest.WriteGraph(c, format, nil)
+	}
+}
+
+// findRevision looks up the version named by a ?rev= query, defaulting to the
+// latest version of the dataset when none is given.
+func findRevision(manifest *core.Manifest, rev string) (*core.Version, error) {
+	if rev == "" {
+		return manifest.LatestVersion()
+	}
+	return manifest.FindVersion(rev)
+}
+
+func (d *Server) DatasetExport() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		dataset, ok := c.Locals("Dataset").(*model.Dataset)
+		if !ok || dataset == nil || dataset.Manifest == nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch dataset manifest",
+			})
+		}
+
+		format := core.ArchiveFormat(c.Query("format", core.ArchiveFormatTar.String()))
+		if !format.IsValid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("unsupported archive format %q", format),
+			})
+		}
+
+		version, err := findRevision(dataset.Manifest, c.Query("rev"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		c.Attachment(dataset.Slug + "-" + version.Hash + format.Extension())
+		c.Set(fiber.HeaderContentType, format.ContentType())
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			if err := d.Ipfs.Export(d, version.Hash, "", version.Hash, format, w); err != nil {
+				log.Printf("export of %s failed: %v", version.Hash, err)
+			}
+			w.Flush()
+		})
+		return nil
+	}
+}
+
+func (d *Server) DatasetDescribe() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		dataset, ok := c.Locals("Dataset").(*model.Dataset)
+		if !ok || dataset == nil || dataset.Manifest == nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to fetch dataset manifest",
+			})
+		}
+
+		format := core.DescribeFormat(c.Query("format", core.DescribeFormatRoCrate.String()))
+		if !format.IsValid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("unsupported description format %q", format),
+			})
+		}
+
+		version, err := findRevision(dataset.Manifest, c.Query("rev"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		desc, err := d.Ipfs.Describe(d, dataset.Manifest, version, core.DatasetInfo{
+			Name:        dataset.Name,
+			Description: dataset.Description,
+			License:     dataset.License,
+			Url:         c.BaseURL() + "/" + dataset.Organization.Slug + "/" + dataset.Slug,
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": fmt.Sprintf("failed to describe version: %v", err),
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, format.ContentType())
+		return desc.Write(c, format)
+	}
+}
@@ -13,6 +13,7 @@ type Dataset struct {
 	Name           string         `json:"name"`
 	Contact        string         `json:"contact"`
 	Description    string         `json:"description"`
+	License        string         `json:"license"`
 	IsPrivate      bool           `json:"private"`
 	OrganizationID uint           `json:"organizationId"`
 	ManifestHash   string         `json:"manifestHash"`
@@ -31,6 +32,7 @@ type NewDataset struct {
 	OrganizationID uint    `json:"organizationId"`
 	Contact        string  `json:"contact"`
 	Description    *string `json:"description,omitempty"`
+	License        string  `json:"license,omitempty"`
 	IsPrivate      bool    `json:"isPrivate"`
 }
 
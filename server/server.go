@@ -150,6 +150,9 @@ func (d *Server) setup() {
 	dataset := organization.Group("/:dataset", d.GetDataset())
 	dataset.Get("/", d.Dataset())
 	dataset.Post("/", d.RecieveDataset())
+	dataset.Get("/graph", d.DatasetGraph())
+	dataset.Get("/export", d.DatasetExport())
+	dataset.Get("/describe", d.DatasetDescribe())
 }
 
 func (d *Server) CreateDataset(dataset model.NewDataset, authUser *model.User) error {
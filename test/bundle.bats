@@ -0,0 +1,109 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+
+  mkdir source
+  cd source || return 1
+  dorothy init
+  dorothy config set user.name "John Doe"
+  dorothy config set user.email "john.doe@39alpharesearch.org"
+  mkdir data
+  echo "one" > data/a.txt
+  dorothy commit -m "first" data
+  FIRST="$(dorothy log | grep -m1 -o 'Qm[[:alnum:]]*')"
+  echo "two" > data/a.txt
+  dorothy commit -m "second" data
+  SECOND="$(dorothy log | grep -m1 -o 'Qm[[:alnum:]]*')"
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+@test "bundle create requires an output file" {
+  run dorothy bundle create
+  [ "$status" -eq 1 ]
+  assert_output "fatal: no output file; see --output"
+}
+
+@test "clone creates a repository from a bundle" {
+  run dorothy bundle create -o ../data.bundle
+  assert_success
+  assert_output --partial "with 2 of 2 version(s)"
+
+  cd ..
+  run dorothy clone data.bundle
+  assert_success
+
+  cd data || return 1
+  run dorothy log
+  assert_line --index 0 "Hash:    $SECOND"
+  assert_output --partial "Hash:    $FIRST"
+
+  run dorothy cat "$FIRST:a.txt"
+  assert_output "one"
+
+  run dorothy fsck
+  assert_success
+}
+
+@test "clone accepts absolute paths to a bundle and destination" {
+  mkdir "$BATS_TEST_TMPDIR/drive"
+  dorothy bundle create -o "$BATS_TEST_TMPDIR/drive/data.bundle"
+
+  mkdir "$BATS_TEST_TMPDIR/elsewhere"
+  cd "$BATS_TEST_TMPDIR/elsewhere" || return 1
+  run dorothy clone "$BATS_TEST_TMPDIR/drive/data.bundle" "$BATS_TEST_TMPDIR/copy"
+  assert_success
+
+  cd "$BATS_TEST_TMPDIR/copy" || return 1
+  run dorothy cat "$SECOND:a.txt"
+  assert_output "two"
+
+  cd "$BATS_TEST_TMPDIR/elsewhere" || return 1
+  run dorothy clone "$BATS_TEST_TMPDIR/drive/data.bundle"
+  assert_success
+  [ -d "$BATS_TEST_TMPDIR/elsewhere/data/.dorothy" ]
+}
+
+@test "bundle fetch merges a range of versions" {
+  dorothy bundle create -o ../data.bundle "$FIRST.."
+
+  mkdir ../dest
+  cd ../dest || return 1
+  dorothy init
+
+  run dorothy bundle fetch ../data.bundle
+  assert_success
+  assert_line --index 1 "complete $SECOND"
+  refute_output --partial "$FIRST"
+
+  run dorothy log
+  assert_output --partial "Hash:    $FIRST"
+
+  run dorothy cat "$SECOND:a.txt"
+  assert_output "two"
+
+  run dorothy fsck
+  [ "$status" -eq 1 ]
+  assert_output --partial "missing-blocks $FIRST"
+}
+
+@test "bundle fetch rejects other archives" {
+  dorothy export -o ../data.car "$FIRST"
+
+  run dorothy bundle fetch ../data.car
+  [ "$status" -eq 1 ]
+  assert_output --partial "is not a dorothy bundle"
+}
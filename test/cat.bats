@@ -0,0 +1,47 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+
+  dorothy init >/dev/null 2>&1
+  dorothy config set user.name "John Doe" >/dev/null
+  dorothy config set user.email "john.doe@39alpharesearch.org" >/dev/null
+
+  mkdir -p data/site-a
+  printf "0123456789" > data/site-a/digits.txt
+  dorothy commit -m "Initial data" data >/dev/null 2>&1
+
+  VERSION=$(dorothy log | awk '/Hash:/ { print $2 }')
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+@test "cat streams a file" {
+  run dorothy cat "$VERSION:site-a/digits.txt"
+  assert_success
+  assert_output "0123456789"
+}
+
+@test "cat streams a byte range" {
+  run dorothy cat --offset 3 --length 4 "$VERSION:site-a/digits.txt"
+  assert_success
+  assert_output "3456"
+}
+
+@test "cat fails on directories" {
+  run dorothy cat "$VERSION:site-a"
+  assert_failure
+  assert_output "fatal: \"site-a\" is not a file"
+}
@@ -98,3 +98,62 @@ teardown() {
   diff data/README.md data2/data/README.md
 }
 
+
+@test "checkout refuses to discard local changes" {
+  mkdir data
+  echo "one" > data/README.md
+  dorothy commit -m "First commit" data
+  local first="$(dorothy log | awk '/Hash/{ print $2 }')"
+
+  echo "two" > data/README.md
+  echo "notes" > data/NOTES.md
+  dorothy commit -m "Second commit" data
+
+  run dorothy show "$(dorothy log | awk '/Hash/{ print $2 }' | head -n 1)"
+  assert_output --regexp "Parents: +$first"
+
+  echo "edited" > data/NOTES.md
+  run dorothy checkout -q "$first" data
+  assert_failure
+  assert_output --partial "NOTES.md"
+
+  echo "notes" > data/NOTES.md
+  echo "scratch" > data/scratch.txt
+  run dorothy checkout -q "$first" data
+  assert_success
+  assert_output --partial "leaving untracked file scratch.txt"
+  run cat data/README.md
+  assert_output "one"
+  [[ ! -e data/NOTES.md ]]
+  [[ -e data/scratch.txt ]]
+
+  echo "edited" > data/README.md
+  run dorothy checkout -q "$first" data2
+  assert_success
+  dorothy checkout -q -f "$first" data
+  run cat data/README.md
+  assert_output "one"
+  [[ -e data/scratch.txt ]]
+}
+
+@test "checkout only tracks destinations when asked" {
+  mkdir data
+  echo "one" > data/README.md
+  echo "two" > data/NOTES.md
+  dorothy commit -m "First commit" data
+  local first="$(dorothy log | awk '/Hash/{ print $2 }')"
+
+  rm data/NOTES.md
+  dorothy commit -m "Second commit" data
+  local second="$(dorothy log | awk '/Hash/{ print $2 }' | head -n 1)"
+
+  dorothy checkout -q "$first" copy
+  echo "scratch" > copy/scratch.txt
+  dorothy checkout -q "$second" copy
+  [[ -e copy/NOTES.md ]]
+  [[ -e copy/scratch.txt ]]
+
+  dorothy checkout -q --track "$first" tracked
+  dorothy checkout -q "$second" tracked
+  [[ ! -e tracked/NOTES.md ]]
+}
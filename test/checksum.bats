@@ -0,0 +1,54 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+
+  dorothy init >/dev/null 2>&1
+  dorothy config set user.name "John Doe" >/dev/null
+  dorothy config set user.email "john.doe@39alpharesearch.org" >/dev/null
+
+  mkdir -p data/site-a
+  echo "hello" > data/README.md
+  echo "a,b" > data/site-a/data.csv
+  dorothy commit -m "Initial data" data >/dev/null 2>&1
+
+  VERSION=$(dorothy log | awk '/Hash:/ { print $2 }')
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+@test "checksum prints sha256sum output which verifies the files" {
+  run dorothy checksum "${VERSION:0:8}"
+  assert_success
+  assert_output --partial "  README.md"
+  assert_output --partial "  site-a/data.csv"
+
+  dorothy checksum > SHA256SUMS
+  cd data
+  run sha256sum -c ../SHA256SUMS
+  assert_success
+}
+
+@test "checksum --md5 prints md5sum output" {
+  dorothy checksum --md5 "$VERSION" > MD5SUMS
+  cd data
+  run md5sum -c ../MD5SUMS
+  assert_success
+}
+
+@test "checksum fails on an unknown revision" {
+  run dorothy checksum nonexistent
+  assert_failure
+}
@@ -167,3 +167,18 @@ teardown() {
   assert_output --partial "$VERSION_HASH recursive"
 }
 
+
+@test "commit --nocopy references files in place" {
+  mkdir data
+  head -c 600000 /dev/urandom > data/blob.bin
+
+  dorothy commit --nocopy -m "Initial commit" data
+
+  run dorothy filestore verify
+  assert_success
+
+  head -c 100 /dev/urandom | dd of=data/blob.bin conv=notrunc 2>/dev/null
+  run dorothy filestore verify
+  assert_failure
+  assert_output --partial "changed"
+}
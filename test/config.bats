@@ -100,3 +100,32 @@ EOL
   run dorothy config get -c custom_config.toml user.name
   assert_output "John Doe"
 }
+
+@test "sets numeric config" {
+  run dorothy config set retention.keep_last 1
+  assert_success
+
+  run dorothy config get retention.keep_last
+  assert_output "1"
+}
+
+@test "sets boolean config from a number" {
+  run dorothy config set ipfs.nocopy 1
+  assert_success
+
+  run dorothy config get ipfs.nocopy
+  assert_success
+  assert_output "true"
+
+  run dorothy config set ipfs.nocopy maybe
+  assert_failure
+  assert_output --partial "ipfs.nocopy must be true or false"
+}
+
+@test "sets string config which looks like a number" {
+  run dorothy config set user.name 1
+  assert_success
+
+  run dorothy config get user.name
+  assert_output "1"
+}
@@ -0,0 +1,42 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+
+@test "fsck fails if outside repository" {
+  run dorothy fsck
+  [ "$status" -eq 1 ]
+  assert_output "fatal: not a dorothy repository"
+}
+
+@test "fsck succeeds in empty repository" {
+  dorothy init
+  run dorothy fsck
+  assert_success
+  assert_output --partial "checked 0 version(s)"
+}
+
+@test "fsck reports a bad manifest pointer" {
+  dorothy init
+  printf "not-a-hash" > .dorothy/manifest
+  run dorothy fsck
+  [ "$status" -eq 1 ]
+  assert_line --index 0 --partial "bad-pointer not-a-hash"
+  assert_line --index 1 "fatal: found 1 problem(s) in manifest not-a-hash"
+}
@@ -0,0 +1,104 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+
+  dorothy init
+  dorothy config set user.name "John Doe"
+  dorothy config set user.email "john.doe@39alpharesearch.org"
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+@test "pins lists committed versions" {
+  mkdir data
+  echo "one" > data/a.txt
+  dorothy commit -m "first" data
+
+  run dorothy pins
+  assert_success
+  assert_line --index 1 "1 pinned version(s), 4 B"
+}
+
+@test "pins omits versions committed without a pin" {
+  mkdir data
+  echo "one" > data/a.txt
+  dorothy commit -m "first" data
+  echo "two" > data/a.txt
+  dorothy commit -N -m "second" data
+  local SECOND
+  SECOND="$(dorothy log | grep -m1 -o 'Qm[[:alnum:]]*')"
+
+  run dorothy pins
+  assert_success
+  refute_output --partial "$SECOND"
+  assert_line --index 1 "1 pinned version(s), 4 B"
+}
+
+@test "gc applies the retention policy" {
+  mkdir data
+  echo "one" > data/a.txt
+  dorothy commit -m "first" data
+  local FIRST
+  FIRST="$(dorothy log | grep -m1 -o 'Qm[[:alnum:]]*')"
+  echo "two" > data/a.txt
+  dorothy commit -m "second" data
+
+  dorothy config set retention.keep_last 1
+
+  run dorothy gc --dry-run
+  assert_output "would unpin $FIRST"
+
+  run dorothy gc
+  assert_line --index 0 "unpinned $FIRST"
+
+  run dorothy pins
+  assert_line --index 1 "1 pinned version(s), 4 B"
+}
+
+@test "tagged versions are kept" {
+  mkdir data
+  echo "one" > data/a.txt
+  dorothy commit -m "first" data
+  local FIRST
+  FIRST="$(dorothy log | grep -m1 -o 'Qm[[:alnum:]]*')"
+  echo "two" > data/a.txt
+  dorothy commit -m "second" data
+
+  dorothy pin --tag v1 "$FIRST"
+  dorothy config set retention.keep_last 1
+  dorothy config set retention.keep_tagged true
+
+  run dorothy gc --dry-run
+  assert_output ""
+
+  run dorothy unpin v1
+  assert_output "unpinned $FIRST"
+}
+
+@test "gc refuses a shared store unless forced" {
+  export XDG_DATA_HOME="$BATS_TEST_TMPDIR/data_home"
+  mkdir shared
+  cd shared || return 1
+  dorothy init --shared
+
+  run dorothy gc
+  assert_failure
+  assert_output --partial "use --force"
+
+  run dorothy gc --force
+  assert_success
+  assert_output --partial "removed"
+}
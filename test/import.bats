@@ -0,0 +1,66 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+
+  mkdir source dest
+  cd source || return 1
+  dorothy init
+  dorothy config set user.name "John Doe"
+  dorothy config set user.email "john.doe@39alpharesearch.org"
+  mkdir data
+  echo "one" > data/a.txt
+  dorothy commit -m "first" data
+  HASH="$(dorothy log | grep -m1 -o 'Qm[[:alnum:]]*')"
+  dorothy export -o "$BATS_TEST_TMPDIR/data.car" "$HASH"
+
+  cd ../dest || return 1
+  dorothy init
+  dorothy config set user.name "Jane Doe"
+  dorothy config set user.email "jane.doe@39alpharesearch.org"
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+@test "import loads the blocks of an archive" {
+  run dorothy import "$BATS_TEST_TMPDIR/data.car"
+  assert_success
+  assert_line --index 0 "imported 2 block(s)"
+  assert_line --index 1 "complete $HASH"
+
+  run dorothy log
+  assert_output "no versions"
+
+  run dorothy cat "$HASH:a.txt"
+  assert_success
+  assert_output "one"
+}
+
+@test "import creates a version from the root" {
+  run dorothy import -m "imported" "$BATS_TEST_TMPDIR/data.car"
+  assert_success
+
+  run dorothy log
+  assert_line --index 0 "Hash:    $HASH"
+  assert_line --index 1 "Author:  Jane Doe <jane.doe@39alpharesearch.org>"
+  assert_output --partial "imported"
+}
+
+@test "import fails on a file which is not an archive" {
+  echo "not a car" > bad.car
+  run dorothy import bad.car
+  [ "$status" -eq 1 ]
+  assert_output --partial "cannot read archive"
+}
@@ -46,5 +46,28 @@ teardown() {
   assert_output --partial "Author:  $NAME <$EMAIL>"
   assert_output --partial "Date:    "
   assert_output --partial "Type:    FILE"
+  assert_output --partial "Size:    0 B in 1 file(s), 0 dir(s)"
   assert_output --partial "    $MESSAGE"
 }
+
+@test "log --graph draws merges" {
+  dorothy init
+  dorothy config set user.name "John Doe"
+  dorothy config set user.email "john.doe@39alpharesearch.org"
+  touch README.md
+  dorothy commit -m "root" README.md
+  local ROOT
+  ROOT=$(dorothy log | awk '/Hash:/ { print $2 }')
+  echo left > left.txt
+  dorothy commit -m "left" -p "$ROOT" left.txt
+  echo right > right.txt
+  dorothy commit -m "right" -p "$ROOT" right.txt
+  local TIPS
+  TIPS=$(dorothy log | awk '/Hash:/ { print $2 }' | head -n 2 | paste -sd, -)
+  echo merge > merge.txt
+  dorothy commit -m "merge" -p "$TIPS" merge.txt
+  run dorothy log --graph
+  assert_line --index 0 --partial "*   Hash:"
+  assert_line --index 1 --partial "|\\  Author:"
+  assert_output --partial "|/  Author:"
+}
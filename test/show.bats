@@ -0,0 +1,75 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+
+  dorothy init >/dev/null 2>&1
+  dorothy config set user.name "John Doe" >/dev/null
+  dorothy config set user.email "john.doe@39alpharesearch.org" >/dev/null
+
+  mkdir -p data/site-a
+  echo "hello" > data/README.md
+  echo "a,b" > data/site-a/data.csv
+  dorothy commit -m "Initial data" data >/dev/null 2>&1
+
+  VERSION=$(dorothy log | awk '/Hash:/ { print $2 }')
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+@test "show prints metadata and summary" {
+  run dorothy show "${VERSION:0:8}"
+  assert_success
+  assert_output --partial "Hash:         $VERSION"
+  assert_output --partial "Type:         DIRECTORY"
+  assert_output --partial "Files:        2"
+  assert_output --partial "Directories:  1"
+  assert_output --partial "Size:         10 B (10 bytes)"
+  assert_output --partial "    Initial data"
+}
+
+@test "show fails for unknown versions" {
+  run dorothy show Qmnotahash
+  assert_failure
+  assert_output "fatal: hash \"Qmnotahash\" not found in manifest"
+}
+
+@test "ls lists the top level of a version" {
+  run dorothy ls "$VERSION"
+  assert_success
+  assert_line --index 0 --regexp "^Qm[[:alnum:]]+  6  README.md$"
+  assert_line --index 1 --regexp "^Qm[[:alnum:]]+  0  site-a/$"
+  refute_output --partial "data.csv"
+}
+
+@test "ls lists recursively" {
+  run dorothy ls -r "$VERSION"
+  assert_success
+  assert_output --partial "site-a/data.csv"
+}
+
+@test "ls lists a subpath" {
+  run dorothy ls "$VERSION:site-a"
+  assert_success
+  assert_output --regexp "^Qm[[:alnum:]]+  4  data.csv$"
+}
+
+@test "ls outputs json" {
+  run dorothy ls --json -r "$VERSION"
+  assert_success
+  assert_equal "$(echo "$output" | jq 'length')" "3"
+  assert_equal "$(echo "$output" | jq -r '.[2].path')" "site-a/data.csv"
+  assert_equal "$(echo "$output" | jq -r '.[2].type')" "file"
+}
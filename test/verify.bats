@@ -0,0 +1,60 @@
+setup() {
+  load 'test_helper/bats-support/load'
+  load 'test_helper/bats-assert/load'
+  load 'test_helper/load'
+
+  cd "$BATS_TEST_TMPDIR" || return 1
+
+  # Prevents loading the global config on the testing box
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/global_config"
+
+  # Prevents writing to global ipfs
+  export IPFS_PATH=".dorothy"
+
+  disallow_ipfs_daemon
+
+  dorothy init >/dev/null 2>&1
+  dorothy config set user.name "John Doe" >/dev/null
+  dorothy config set user.email "john.doe@39alpharesearch.org" >/dev/null
+
+  mkdir -p data/site-a
+  echo "hello" > data/README.md
+  echo "a,b" > data/site-a/data.csv
+  dorothy commit -m "Initial data" data >/dev/null 2>&1
+
+  VERSION=$(dorothy log | awk '/Hash:/ { print $2 }')
+}
+
+teardown() {
+  cd "$PROJECT_ROOT" || return 1
+}
+
+@test "verify accepts an unchanged directory" {
+  run dorothy verify data "${VERSION:0:8}"
+  assert_success
+  assert_output --partial "matches version $VERSION"
+}
+
+@test "verify defaults to the version the directory was committed as" {
+  run dorothy verify data
+  assert_success
+  assert_output --partial "matches version $VERSION"
+}
+
+@test "verify reports modified, missing and extra files" {
+  echo "goodbye" > data/README.md
+  rm data/site-a/data.csv
+  echo "x" > data/extra.txt
+
+  run dorothy verify data "$VERSION"
+  assert_failure
+  assert_output --partial "modified  README.md"
+  assert_output --partial "missing   site-a/data.csv"
+  assert_output --partial "extra     extra.txt"
+  assert_output --partial "differs from version $VERSION in 3 file(s)"
+}
+
+@test "verify fails on a directory which does not exist" {
+  run dorothy verify nonexistent "$VERSION"
+  assert_failure
+}